@@ -13,6 +13,7 @@ import (
 
 	"github.com/vntrieu/avalon/internal/database"
 	"github.com/vntrieu/avalon/internal/httpapi"
+	"github.com/vntrieu/avalon/internal/websocket"
 )
 
 func main() {
@@ -45,8 +46,18 @@ func main() {
 		tokenSecret = []byte("dev-secret-change-in-production")
 	}
 
+	// Broadcasts reach clients on every instance through Postgres LISTEN/NOTIFY; "local" keeps them on this one.
+	var hubBackend websocket.Backend
+	switch backend := getenv("AVALON_HUB_BACKEND", "postgres"); backend {
+	case "postgres":
+		hubBackend = websocket.NewPGNotifyBackend(dbPool)
+	case "local":
+	default:
+		log.Fatalf("unknown AVALON_HUB_BACKEND %q (want postgres or local)", backend)
+	}
+
 	// Pass nil for rateLimiter to disable; use httpapi.DefaultRateLimiter() to enable (20/min per IP).
-	router := httpapi.NewRouter(dbPool, tokenSecret, nil)
+	router := httpapi.NewRouter(dbPool, tokenSecret, nil, hubBackend)
 
 	srv := &http.Server{
 		Addr:         addr,
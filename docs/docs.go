@@ -266,9 +266,9 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Bad request or room has no players",
+                        "description": "Invalid config (JSON field errors); other bad requests are plain text",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/internal_httpapi_handler.ConfigErrorResponse"
                         }
                     },
                     "401": {
@@ -305,7 +305,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Join an existing room. Requires user token; display_name is taken from user profile.",
+                "description": "Join an existing room. Requires user token; display_name is taken from user profile. A user who is already a player in the room gets their room player back.",
                 "consumes": [
                     "application/json"
                 ],
@@ -374,6 +374,82 @@ const docTemplate = `{
                 }
             }
         },
+        "/api/rooms/{code}/spectate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Watch a room without joining it as a player. Requires user token; display_name is taken from user profile. The spectator token gives read-only access to the room WebSocket.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Spectate room",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Room code (6 alphanumeric)",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Request body (password optional)",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_vntrieu_avalon_internal_store.JoinRoomRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_vntrieu_avalon_internal_store.SpectateRoomResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized or password required/invalid",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Room not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Already a player in this room",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/api/users/me": {
             "get": {
                 "security": [
@@ -427,6 +503,17 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "github_com_vntrieu_avalon_internal_games.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
         "github_com_vntrieu_avalon_internal_store.CreateGameResponse": {
             "type": "object",
             "properties": {
@@ -494,7 +581,7 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "status": {
-                    "description": "waiting | in_progress | finished",
+                    "description": "waiting | in_progress | paused | finished",
                     "type": "string"
                 }
             }
@@ -624,6 +711,29 @@ const docTemplate = `{
                 }
             }
         },
+        "github_com_vntrieu_avalon_internal_store.SpectateRoomResponse": {
+            "type": "object",
+            "properties": {
+                "display_name": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "latest_game": {
+                    "$ref": "#/definitions/github_com_vntrieu_avalon_internal_store.Game"
+                },
+                "room": {
+                    "$ref": "#/definitions/github_com_vntrieu_avalon_internal_store.Room"
+                },
+                "spectator_id": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
         "github_com_vntrieu_avalon_internal_store.User": {
             "type": "object",
             "properties": {
@@ -661,6 +771,23 @@ const docTemplate = `{
                 }
             }
         },
+        "internal_httpapi_handler.ConfigErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_vntrieu_avalon_internal_games.FieldError"
+                    }
+                }
+            }
+        },
         "internal_httpapi_handler.LoginRequest": {
             "type": "object",
             "properties": {
@@ -11,9 +11,13 @@ import (
 )
 
 // Claims holds room and player identity for WebSocket auth.
+// For a spectator token, Spectator is set, RoomPlayerID is the spectator's ID (not a room player) and
+// DisplayName is their name.
 type Claims struct {
 	RoomID       string `json:"room_id"`
 	RoomPlayerID string `json:"room_player_id"`
+	Spectator    bool   `json:"spectator,omitempty"`
+	DisplayName  string `json:"display_name,omitempty"`
 	Exp          int64  `json:"exp"`
 }
 
@@ -29,15 +33,22 @@ const DefaultTokenExpiry = 24 * time.Hour
 // GenerateToken creates an HMAC-SHA256 signed token with room_id, room_player_id, and expiry.
 // Format: base64url(payload).base64url(signature).
 func GenerateToken(roomID, roomPlayerID string, secret []byte, expiry time.Duration) (token string, expiresAt time.Time, err error) {
+	return generateRoomToken(Claims{RoomID: roomID, RoomPlayerID: roomPlayerID}, secret, expiry)
+}
+
+// GenerateSpectatorToken creates a room token for a spectator: read-only access to the room WebSocket
+// as spectatorID (see Claims).
+func GenerateSpectatorToken(roomID, spectatorID, displayName string, secret []byte, expiry time.Duration) (token string, expiresAt time.Time, err error) {
+	return generateRoomToken(Claims{RoomID: roomID, RoomPlayerID: spectatorID, Spectator: true, DisplayName: displayName}, secret, expiry)
+}
+
+// generateRoomToken signs claims with their expiry set from expiry.
+func generateRoomToken(claims Claims, secret []byte, expiry time.Duration) (token string, expiresAt time.Time, err error) {
 	if len(secret) == 0 {
 		return "", time.Time{}, fmt.Errorf("token secret is required")
 	}
 	expiresAt = time.Now().UTC().Add(expiry)
-	claims := Claims{
-		RoomID:       roomID,
-		RoomPlayerID: roomPlayerID,
-		Exp:          expiresAt.Unix(),
-	}
+	claims.Exp = expiresAt.Unix()
 	payload, err := json.Marshal(claims)
 	if err != nil {
 		return "", time.Time{}, fmt.Errorf("marshal claims: %w", err)
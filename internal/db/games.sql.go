@@ -108,6 +108,30 @@ func (q *Queries) GetGameById(ctx context.Context, id pgtype.UUID) (Game, error)
 	return i, err
 }
 
+const getGameStateSnapshotByVersion = `-- name: GetGameStateSnapshotByVersion :one
+SELECT id, game_id, version, state_json, created_at
+FROM game_state_snapshots
+WHERE game_id = $1 AND version = $2
+`
+
+type GetGameStateSnapshotByVersionParams struct {
+	GameID  pgtype.UUID `json:"game_id"`
+	Version int32       `json:"version"`
+}
+
+func (q *Queries) GetGameStateSnapshotByVersion(ctx context.Context, arg GetGameStateSnapshotByVersionParams) (GameStateSnapshot, error) {
+	row := q.db.QueryRow(ctx, getGameStateSnapshotByVersion, arg.GameID, arg.Version)
+	var i GameStateSnapshot
+	err := row.Scan(
+		&i.ID,
+		&i.GameID,
+		&i.Version,
+		&i.StateJson,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const getGamesByRoomId = `-- name: GetGamesByRoomId :many
 SELECT id, room_id, status, config_json, created_at, ended_at
 FROM games
@@ -226,6 +250,95 @@ func (q *Queries) GetRoomPlayersByRoomId(ctx context.Context, roomID pgtype.UUID
 	return items, nil
 }
 
+const listGamesPastDeadline = `-- name: ListGamesPastDeadline :many
+SELECT g.id, g.room_id, g.status, g.config_json, g.created_at, g.ended_at
+FROM games g
+JOIN LATERAL (
+    SELECT state_json
+    FROM game_state_snapshots
+    WHERE game_id = g.id
+    ORDER BY version DESC
+    LIMIT 1
+) s ON true
+WHERE g.status = 'in_progress'
+  AND s.state_json ? 'deadline'
+  AND (s.state_json->>'deadline')::timestamptz <= $1::timestamptz
+`
+
+func (q *Queries) ListGamesPastDeadline(ctx context.Context, now pgtype.Timestamptz) ([]Game, error) {
+	rows, err := q.db.Query(ctx, listGamesPastDeadline, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Game{}
+	for rows.Next() {
+		var i Game
+		if err := rows.Scan(
+			&i.ID,
+			&i.RoomID,
+			&i.Status,
+			&i.ConfigJson,
+			&i.CreatedAt,
+			&i.EndedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockGameForUpdate = `-- name: LockGameForUpdate :one
+SELECT id
+FROM games
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockGameForUpdate(ctx context.Context, id pgtype.UUID) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, lockGameForUpdate, id)
+	err := row.Scan(&id)
+	return id, err
+}
+
+const setGamePlayerLeftAt = `-- name: SetGamePlayerLeftAt :exec
+UPDATE game_players
+SET left_at = $3
+WHERE game_id = $1 AND room_player_id = $2
+`
+
+type SetGamePlayerLeftAtParams struct {
+	GameID       pgtype.UUID        `json:"game_id"`
+	RoomPlayerID pgtype.UUID        `json:"room_player_id"`
+	LeftAt       pgtype.Timestamptz `json:"left_at"`
+}
+
+func (q *Queries) SetGamePlayerLeftAt(ctx context.Context, arg SetGamePlayerLeftAtParams) error {
+	_, err := q.db.Exec(ctx, setGamePlayerLeftAt, arg.GameID, arg.RoomPlayerID, arg.LeftAt)
+	return err
+}
+
+const updateGamePlayerRole = `-- name: UpdateGamePlayerRole :exec
+UPDATE game_players
+SET role = $3
+WHERE game_id = $1 AND room_player_id = $2
+`
+
+type UpdateGamePlayerRoleParams struct {
+	GameID       pgtype.UUID `json:"game_id"`
+	RoomPlayerID pgtype.UUID `json:"room_player_id"`
+	Role         pgtype.Text `json:"role"`
+}
+
+func (q *Queries) UpdateGamePlayerRole(ctx context.Context, arg UpdateGamePlayerRoleParams) error {
+	_, err := q.db.Exec(ctx, updateGamePlayerRole, arg.GameID, arg.RoomPlayerID, arg.Role)
+	return err
+}
+
 const updateGameStatus = `-- name: UpdateGameStatus :exec
 UPDATE games
 SET status = $2, ended_at = $3
@@ -242,3 +355,21 @@ func (q *Queries) UpdateGameStatus(ctx context.Context, arg UpdateGameStatusPara
 	_, err := q.db.Exec(ctx, updateGameStatus, arg.ID, arg.Status, arg.EndedAt)
 	return err
 }
+
+const upsertGamePlayer = `-- name: UpsertGamePlayer :exec
+INSERT INTO game_players (game_id, room_player_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (game_id, room_player_id) DO UPDATE
+SET role = EXCLUDED.role, left_at = NULL
+`
+
+type UpsertGamePlayerParams struct {
+	GameID       pgtype.UUID `json:"game_id"`
+	RoomPlayerID pgtype.UUID `json:"room_player_id"`
+	Role         pgtype.Text `json:"role"`
+}
+
+func (q *Queries) UpsertGamePlayer(ctx context.Context, arg UpsertGamePlayerParams) error {
+	_, err := q.db.Exec(ctx, upsertGamePlayer, arg.GameID, arg.RoomPlayerID, arg.Role)
+	return err
+}
@@ -52,6 +52,12 @@ type GameStateSnapshot struct {
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
 }
 
+type HubMessage struct {
+	ID          int64              `json:"id"`
+	PayloadJson []byte             `json:"payload_json"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
 type Room struct {
 	ID           pgtype.UUID        `json:"id"`
 	Code         string             `json:"code"`
@@ -80,3 +86,11 @@ type User struct {
 	CreatedAt    pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
 }
+
+type WsReply struct {
+	RoomID        string             `json:"room_id"`
+	PlayerID      string             `json:"player_id"`
+	CorrelationID string             `json:"correlation_id"`
+	ReplyJson     []byte             `json:"reply_json"`
+	ExpiresAt     pgtype.Timestamptz `json:"expires_at"`
+}
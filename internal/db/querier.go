@@ -14,6 +14,7 @@ type Querier interface {
 	CheckDisplayNameExists(ctx context.Context, arg CheckDisplayNameExistsParams) (bool, error)
 	CheckRoomCodeExists(ctx context.Context, code string) (bool, error)
 	CheckUserEmailExists(ctx context.Context, email string) (bool, error)
+	ClaimWsReply(ctx context.Context, arg ClaimWsReplyParams) (string, error)
 	CountRoomPlayersById(ctx context.Context, id pgtype.UUID) (int64, error)
 	CountRoomPlayersByRoomId(ctx context.Context, roomID pgtype.UUID) (int64, error)
 	CountRoomsById(ctx context.Context, id pgtype.UUID) (int64, error)
@@ -22,13 +23,20 @@ type Querier interface {
 	CreateGameEvent(ctx context.Context, arg CreateGameEventParams) (GameEvent, error)
 	CreateGamePlayer(ctx context.Context, arg CreateGamePlayerParams) (GamePlayer, error)
 	CreateGameStateSnapshot(ctx context.Context, arg CreateGameStateSnapshotParams) (GameStateSnapshot, error)
+	CreateHubMessage(ctx context.Context, payloadJson []byte) (int64, error)
 	CreateRoom(ctx context.Context, arg CreateRoomParams) (CreateRoomRow, error)
 	CreateRoomPlayer(ctx context.Context, arg CreateRoomPlayerParams) (CreateRoomPlayerRow, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	DeleteExpiredWsReplies(ctx context.Context, expiresAt pgtype.Timestamptz) error
+	DeleteHubMessagesBefore(ctx context.Context, createdAt pgtype.Timestamptz) error
+	DeleteWsReply(ctx context.Context, arg DeleteWsReplyParams) error
+	FinishWsReply(ctx context.Context, arg FinishWsReplyParams) error
 	GetGameById(ctx context.Context, id pgtype.UUID) (Game, error)
 	GetGameEventsByGameId(ctx context.Context, gameID pgtype.UUID) ([]GameEvent, error)
 	GetGameEventsByGameIdAfter(ctx context.Context, arg GetGameEventsByGameIdAfterParams) ([]GameEvent, error)
+	GetGameStateSnapshotByVersion(ctx context.Context, arg GetGameStateSnapshotByVersionParams) (GameStateSnapshot, error)
 	GetGamesByRoomId(ctx context.Context, roomID pgtype.UUID) ([]Game, error)
+	GetHubMessage(ctx context.Context, id int64) ([]byte, error)
 	GetLatestGameStateSnapshotByGameId(ctx context.Context, gameID pgtype.UUID) (GameStateSnapshot, error)
 	GetRoomByCode(ctx context.Context, code string) (GetRoomByCodeRow, error)
 	GetRoomById(ctx context.Context, id pgtype.UUID) (Room, error)
@@ -39,7 +47,14 @@ type Querier interface {
 	GetRoomPlayersByRoomId(ctx context.Context, roomID pgtype.UUID) ([]GetRoomPlayersByRoomIdRow, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserByID(ctx context.Context, id pgtype.UUID) (User, error)
+	GetWsReply(ctx context.Context, arg GetWsReplyParams) ([]byte, error)
+	ListGamesPastDeadline(ctx context.Context, now pgtype.Timestamptz) ([]Game, error)
+	LockGameForUpdate(ctx context.Context, id pgtype.UUID) (pgtype.UUID, error)
+	NotifyHub(ctx context.Context, arg NotifyHubParams) error
+	SetGamePlayerLeftAt(ctx context.Context, arg SetGamePlayerLeftAtParams) error
+	UpdateGamePlayerRole(ctx context.Context, arg UpdateGamePlayerRoleParams) error
 	UpdateGameStatus(ctx context.Context, arg UpdateGameStatusParams) error
+	UpsertGamePlayer(ctx context.Context, arg UpsertGamePlayerParams) error
 }
 
 var _ Querier = (*Queries)(nil)
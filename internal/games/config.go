@@ -1,5 +1,7 @@
 package games
 
+import "fmt"
+
 // PhaseDef defines a phase: name and allowed action types.
 type PhaseDef struct {
 	Name           string   `json:"name"`
@@ -8,13 +10,62 @@ type PhaseDef struct {
 
 // RulesConfig holds phase sequence and constraints (e.g. team size per round).
 type RulesConfig struct {
+	// Preset names the base configuration the overrides were applied to (see Presets).
+	Preset     string     `json:"preset,omitempty"`
 	Phases    []PhaseDef `json:"phases"`
 	MinPlayers int       `json:"min_players"`
 	MaxPlayers int       `json:"max_players"`
-	// TeamSizes per round (1-based round index). If nil, use default Avalon sizes.
+	// TeamSizes per round (1-based round index). If nil, derived from the player count when the game starts.
 	TeamSizes []int `json:"team_sizes,omitempty"`
 	// FailThreshold: number of mission failures for evil to win (default 3).
 	FailThreshold int `json:"fail_threshold,omitempty"`
+	// FailsRequired per round (1-based round index): fail cards needed to fail the mission. If nil, use default Avalon values.
+	FailsRequired []int `json:"fails_required,omitempty"`
+	// MaxRejections: consecutive team rejections that end the game with evil winning (default 5).
+	MaxRejections int `json:"max_rejections,omitempty"`
+	// Roles: special roles chosen by the host (see RoleCatalog). Remaining seats are loyal servants and minions.
+	Roles []string `json:"roles,omitempty"`
+	// Timers: per-phase turn limits; zero values disable the timer.
+	Timers TimerConfig `json:"timers"`
+	// Modules: optional rule modules.
+	Modules ModulesConfig `json:"modules"`
+}
+
+// TimerConfig holds per-phase turn limits in seconds (0 = no limit).
+type TimerConfig struct {
+	ProposalSeconds      int `json:"proposal_seconds,omitempty"`
+	VoteSeconds          int `json:"vote_seconds,omitempty"`
+	MissionSeconds       int `json:"mission_seconds,omitempty"`
+	AssassinationSeconds int `json:"assassination_seconds,omitempty"`
+	LadyOfTheLakeSeconds int `json:"lady_of_the_lake_seconds,omitempty"`
+}
+
+// SecondsFor returns the turn limit for phase (0 = no limit).
+func (t TimerConfig) SecondsFor(phase string) int {
+	switch phase {
+	case PhaseTeamSelection:
+		return t.ProposalSeconds
+	case PhaseTeamVote:
+		return t.VoteSeconds
+	case PhaseMissionVote:
+		return t.MissionSeconds
+	case PhaseLadyOfTheLake:
+		return t.LadyOfTheLakeSeconds
+	case PhaseAssassination:
+		return t.AssassinationSeconds
+	}
+	return 0
+}
+
+// ModulesConfig toggles optional rule modules.
+type ModulesConfig struct {
+	// LadyOfTheLake: after missions 2, 3 and 4 the token holder privately inspects another player's alignment.
+	LadyOfTheLake bool `json:"lady_of_the_lake,omitempty"`
+}
+
+// LadyOfTheLakeAfterRound reports whether the Lady of the Lake is used after mission round (1-based).
+func LadyOfTheLakeAfterRound(round int) bool {
+	return round >= 2 && round <= 4
 }
 
 // ClassicAvalonPhases defines the phase sequence for classic Avalon.
@@ -24,6 +75,8 @@ var ClassicAvalonPhases = []PhaseDef{
 	{Name: PhaseTeamVote, AllowedActions: []string{ActionVote}},
 	{Name: PhaseMissionVote, AllowedActions: []string{ActionVote}},
 	{Name: PhaseMissionResolution, AllowedActions: []string{}}, // system only
+	{Name: PhaseLadyOfTheLake, AllowedActions: []string{ActionInspect}},
+	{Name: PhaseAssassination, AllowedActions: []string{ActionAssassinate}},
 	{Name: PhaseFinished, AllowedActions: []string{}},
 }
 
@@ -34,6 +87,8 @@ const (
 	PhaseTeamVote          = "team_vote"
 	PhaseMissionVote       = "mission_vote"
 	PhaseMissionResolution = "mission_resolution"
+	PhaseLadyOfTheLake     = "lady_of_the_lake"
+	PhaseAssassination     = "assassination"
 	PhaseFinished          = "finished"
 )
 
@@ -43,8 +98,33 @@ const (
 	ActionProposeTeam  = "propose_team"
 	ActionVote         = "vote"
 	ActionMissionVote  = "vote" // same type, different phase
+	ActionAssassinate  = "assassinate"
+	ActionInspect      = "inspect"
+	// Host actions, allowed in any phase of a started game (see IsHostAction).
+	ActionPauseGame  = "pause_game"
+	ActionResumeGame = "resume_game"
+	ActionAbortGame  = "abort_game"
+	// Host seat actions, for a player disconnected longer than ReconnectGracePeriod.
+	ActionReplacePlayer = "replace_player"
+	ActionFlagSeat      = "flag_seat"
 )
 
+// MissionCount is the number of mission rounds in a game.
+const MissionCount = 5
+
+// ValidateTeamSizes checks a per-round team size table: one entry per mission, each between 1 and n.
+func ValidateTeamSizes(sizes []int, n int) error {
+	if len(sizes) != MissionCount {
+		return fmt.Errorf("team_sizes must have %d entries, got %d", MissionCount, len(sizes))
+	}
+	for i, size := range sizes {
+		if size < 1 || size > n {
+			return fmt.Errorf("team_sizes[%d] = %d must be between 1 and %d", i, size, n)
+		}
+	}
+	return nil
+}
+
 // DefaultTeamSizesForPlayerCount returns mission team sizes for 5–10 players (classic Avalon).
 func DefaultTeamSizesForPlayerCount(n int) []int {
 	switch n {
@@ -66,12 +146,50 @@ func DefaultTeamSizesForPlayerCount(n int) []int {
 	}
 }
 
+// DefaultFailsRequiredForPlayerCount returns fail cards needed per mission (classic Avalon):
+// one everywhere, except two on the fourth mission with 7 or more players.
+func DefaultFailsRequiredForPlayerCount(n int) []int {
+	if n >= 7 {
+		return []int{1, 1, 1, 2, 1}
+	}
+	return []int{1, 1, 1, 1, 1}
+}
+
 // ClassicAvalonConfig returns a RulesConfig for classic Avalon.
 func ClassicAvalonConfig() RulesConfig {
 	return RulesConfig{
+		Preset:         PresetClassic,
 		Phases:         ClassicAvalonPhases,
 		MinPlayers:     5,
 		MaxPlayers:     10,
 		FailThreshold:  3,
+		MaxRejections:  5,
+		Roles:          []string{RoleMerlin, RoleAssassin},
 	}
 }
+
+// Preset names.
+const (
+	PresetClassic  = "classic"
+	PresetVanilla  = "vanilla"
+	PresetAdvanced = "advanced"
+)
+
+// Presets maps preset name to a constructor for its RulesConfig.
+var Presets = map[string]func() RulesConfig{
+	PresetClassic: ClassicAvalonConfig,
+	// PresetVanilla: no special roles, only loyal servants and minions.
+	PresetVanilla: func() RulesConfig {
+		cfg := ClassicAvalonConfig()
+		cfg.Preset = PresetVanilla
+		cfg.Roles = nil
+		return cfg
+	},
+	// PresetAdvanced: Merlin, Percival, Assassin and Morgana.
+	PresetAdvanced: func() RulesConfig {
+		cfg := ClassicAvalonConfig()
+		cfg.Preset = PresetAdvanced
+		cfg.Roles = []string{RoleMerlin, RolePercival, RoleAssassin, RoleMorgana}
+		return cfg
+	},
+}
@@ -0,0 +1,293 @@
+package games
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MaxTimerSeconds is the largest per-phase timer a config may set.
+const MaxTimerSeconds = 3600
+
+// FieldError describes one invalid field in a game config.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ConfigError is returned by ParseConfig with one entry per invalid field.
+type ConfigError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ConfigError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Field+": "+f.Message)
+	}
+	return "invalid config: " + strings.Join(parts, "; ")
+}
+
+func (e *ConfigError) add(field, format string, args ...interface{}) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// ParseConfig builds a RulesConfig from a game's config_json: the named preset (default "classic")
+// with per-field overrides for roles, team_sizes, fails_required, fail_threshold, max_rejections, timers and modules.
+// Checks that do not depend on the player count are done here; the rest happen when the game starts.
+// Returns *ConfigError listing every invalid field.
+func ParseConfig(configJSON map[string]interface{}) (RulesConfig, error) {
+	cfg, errs := parseConfig(configJSON)
+	if len(errs.Fields) > 0 {
+		return RulesConfig{}, errs
+	}
+	return cfg, nil
+}
+
+// parseConfig applies every valid field of configJSON to its preset and lists the invalid ones in errs,
+// which leave the preset's value in place.
+func parseConfig(configJSON map[string]interface{}) (RulesConfig, *ConfigError) {
+	cfg := ClassicAvalonConfig()
+	errs := &ConfigError{}
+	if v, ok := configJSON["preset"]; ok && v != nil {
+		name, _ := v.(string)
+		build, ok := Presets[name]
+		if !ok {
+			errs.add("preset", "unknown preset %q", name)
+		} else {
+			cfg = build()
+		}
+	}
+
+	for key, v := range configJSON {
+		if v == nil {
+			continue
+		}
+		switch key {
+		case "preset":
+		case "roles":
+			roles, ok := strictStringSlice(v)
+			if !ok {
+				errs.add(key, "must be an array of role names")
+				continue
+			}
+			if err := validateRoleNames(roles); err != nil {
+				errs.add(key, "%s", err.Error())
+				continue
+			}
+			cfg.Roles = roles
+		case "team_sizes":
+			sizes, ok := strictIntSlice(v)
+			if !ok || len(sizes) != MissionCount {
+				errs.add(key, "must be an array of %d integers", MissionCount)
+				continue
+			}
+			if !allInRange(sizes, 1, cfg.MaxPlayers) {
+				errs.add(key, "each team size must be between 1 and %d", cfg.MaxPlayers)
+				continue
+			}
+			cfg.TeamSizes = sizes
+		case "fails_required":
+			fails, ok := strictIntSlice(v)
+			if !ok || len(fails) != MissionCount {
+				errs.add(key, "must be an array of %d integers", MissionCount)
+				continue
+			}
+			if !allInRange(fails, 1, cfg.MaxPlayers) {
+				errs.add(key, "each value must be between 1 and %d", cfg.MaxPlayers)
+				continue
+			}
+			cfg.FailsRequired = fails
+		case "fail_threshold":
+			n, ok := strictInt(v)
+			if !ok || n < 1 || n > MissionCount {
+				errs.add(key, "must be an integer between 1 and %d", MissionCount)
+				continue
+			}
+			cfg.FailThreshold = n
+		case "max_rejections":
+			n, ok := strictInt(v)
+			if !ok || n < 1 {
+				errs.add(key, "must be a positive integer")
+				continue
+			}
+			cfg.MaxRejections = n
+		case "timers":
+			parseTimers(v, &cfg.Timers, errs)
+		case "modules":
+			parseModules(v, &cfg.Modules, errs)
+		default:
+			errs.add(key, "unknown field")
+		}
+	}
+
+	if len(cfg.TeamSizes) == MissionCount && len(cfg.FailsRequired) == MissionCount {
+		for i := range cfg.FailsRequired {
+			if cfg.FailsRequired[i] > cfg.TeamSizes[i] {
+				errs.add("fails_required", "mission %d needs %d fails but the team has %d members", i+1, cfg.FailsRequired[i], cfg.TeamSizes[i])
+				cfg.FailsRequired = nil
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(errs.Fields, func(i, j int) bool { return errs.Fields[i].Field < errs.Fields[j].Field })
+	return cfg, errs
+}
+
+func parseTimers(v interface{}, timers *TimerConfig, errs *ConfigError) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		errs.add("timers", "must be an object")
+		return
+	}
+	fields := map[string]*int{
+		"proposal_seconds":         &timers.ProposalSeconds,
+		"vote_seconds":             &timers.VoteSeconds,
+		"mission_seconds":          &timers.MissionSeconds,
+		"assassination_seconds":    &timers.AssassinationSeconds,
+		"lady_of_the_lake_seconds": &timers.LadyOfTheLakeSeconds,
+	}
+	for key, raw := range m {
+		dst, ok := fields[key]
+		if !ok {
+			errs.add("timers."+key, "unknown field")
+			continue
+		}
+		n, ok := strictInt(raw)
+		if !ok || n < 0 || n > MaxTimerSeconds {
+			errs.add("timers."+key, "must be an integer between 0 and %d", MaxTimerSeconds)
+			continue
+		}
+		*dst = n
+	}
+}
+
+func parseModules(v interface{}, modules *ModulesConfig, errs *ConfigError) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		errs.add("modules", "must be an object")
+		return
+	}
+	for key, raw := range m {
+		switch key {
+		case "lady_of_the_lake":
+			b, ok := raw.(bool)
+			if !ok {
+				errs.add("modules."+key, "must be a boolean")
+				continue
+			}
+			modules.LadyOfTheLake = b
+		default:
+			errs.add("modules."+key, "unknown module")
+		}
+	}
+}
+
+// ToMap returns the normalized config_json stored with a game: the preset plus every overridable field,
+// so ParseConfig(c.ToMap()) yields the same rules.
+func (c RulesConfig) ToMap() map[string]interface{} {
+	roles := c.Roles
+	if roles == nil {
+		roles = []string{}
+	}
+	m := map[string]interface{}{
+		"roles":          roles,
+		"fail_threshold": c.FailThreshold,
+		"max_rejections": c.MaxRejections,
+		"timers": map[string]interface{}{
+			"proposal_seconds":         c.Timers.ProposalSeconds,
+			"vote_seconds":             c.Timers.VoteSeconds,
+			"mission_seconds":          c.Timers.MissionSeconds,
+			"assassination_seconds":    c.Timers.AssassinationSeconds,
+			"lady_of_the_lake_seconds": c.Timers.LadyOfTheLakeSeconds,
+		},
+		"modules": map[string]interface{}{
+			"lady_of_the_lake": c.Modules.LadyOfTheLake,
+		},
+	}
+	if c.Preset != "" {
+		m["preset"] = c.Preset
+	}
+	if len(c.TeamSizes) > 0 {
+		m["team_sizes"] = c.TeamSizes
+	}
+	if len(c.FailsRequired) > 0 {
+		m["fails_required"] = c.FailsRequired
+	}
+	return m
+}
+
+// LoadConfigFromMap loads the rules of an existing game from its stored config_json. Unlike ParseConfig it never
+// rejects the config: fields that do not parse (e.g. keys this server does not know) keep the preset's value and
+// are listed in the returned *ConfigError, so games stored under other rules keep playing. Use ParseConfig for
+// configs sent by clients.
+func LoadConfigFromMap(configJSON map[string]interface{}) (RulesConfig, error) {
+	cfg, errs := parseConfig(configJSON)
+	if len(errs.Fields) > 0 {
+		return cfg, errs
+	}
+	return cfg, nil
+}
+
+// strictInt accepts an int or an integral float64 (JSON number).
+func strictInt(a interface{}) (int, bool) {
+	switch v := a.(type) {
+	case int:
+		return v, true
+	case float64:
+		if v != float64(int(v)) {
+			return 0, false
+		}
+		return int(v), true
+	default:
+		return 0, false
+	}
+}
+
+// strictIntSlice is like intSlice but fails if any element is not an integer.
+func strictIntSlice(a interface{}) ([]int, bool) {
+	switch v := a.(type) {
+	case []int:
+		return v, true
+	case []interface{}:
+		out := make([]int, 0, len(v))
+		for _, x := range v {
+			n, ok := strictInt(x)
+			if !ok {
+				return nil, false
+			}
+			out = append(out, n)
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+// strictStringSlice is like stringSlice but fails if any element is not a string.
+func strictStringSlice(a interface{}) ([]string, bool) {
+	switch v := a.(type) {
+	case []string:
+		return v, true
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, x := range v {
+			s, ok := x.(string)
+			if !ok {
+				return nil, false
+			}
+			out = append(out, s)
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+func allInRange(values []int, min, max int) bool {
+	for _, v := range values {
+		if v < min || v > max {
+			return false
+		}
+	}
+	return true
+}
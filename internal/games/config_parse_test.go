@@ -0,0 +1,106 @@
+package games
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseConfig_Empty(t *testing.T) {
+	cfg, err := ParseConfig(nil)
+	if err != nil {
+		t.Fatalf("expected success: %v", err)
+	}
+	if cfg.Preset != PresetClassic || cfg.MaxRejections != 5 || len(cfg.Roles) != 2 {
+		t.Errorf("expected classic defaults, got %+v", cfg)
+	}
+}
+
+func TestParseConfig_PresetWithOverrides(t *testing.T) {
+	var raw map[string]interface{}
+	err := json.Unmarshal([]byte(`{
+		"preset": "advanced",
+		"team_sizes": [2, 3, 3, 4, 4],
+		"fails_required": [1, 1, 1, 2, 1],
+		"max_rejections": 3,
+		"timers": {"vote_seconds": 60},
+		"modules": {"lady_of_the_lake": true}
+	}`), &raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := ParseConfig(raw)
+	if err != nil {
+		t.Fatalf("expected success: %v", err)
+	}
+	if len(cfg.Roles) != 4 || cfg.TeamSizes[3] != 4 || cfg.FailsRequired[3] != 2 || cfg.MaxRejections != 3 {
+		t.Errorf("overrides not applied: %+v", cfg)
+	}
+	if cfg.Timers.VoteSeconds != 60 || !cfg.Modules.LadyOfTheLake {
+		t.Errorf("timers/modules not applied: %+v", cfg)
+	}
+
+	// Normalized map parses back to the same rules.
+	b, _ := json.Marshal(cfg.ToMap())
+	var stored map[string]interface{}
+	_ = json.Unmarshal(b, &stored)
+	back, err := ParseConfig(stored)
+	if err != nil {
+		t.Fatalf("re-parse normalized config: %v", err)
+	}
+	if back.Preset != cfg.Preset || len(back.Roles) != len(cfg.Roles) || back.Timers != cfg.Timers || back.Modules != cfg.Modules {
+		t.Errorf("round trip mismatch: got %+v want %+v", back, cfg)
+	}
+}
+
+func TestParseConfig_FieldErrors(t *testing.T) {
+	var raw map[string]interface{}
+	err := json.Unmarshal([]byte(`{
+		"preset": "chaos",
+		"roles": ["percival"],
+		"team_sizes": [2, 3],
+		"max_rejections": 0,
+		"timers": {"vote_seconds": -1},
+		"bogus": true
+	}`), &raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ParseConfig(raw)
+	cfgErr, ok := err.(*ConfigError)
+	if !ok {
+		t.Fatalf("expected *ConfigError, got %v", err)
+	}
+	fields := make(map[string]bool)
+	for _, f := range cfgErr.Fields {
+		fields[f.Field] = true
+	}
+	for _, want := range []string{"preset", "roles", "team_sizes", "max_rejections", "timers.vote_seconds", "bogus"} {
+		if !fields[want] {
+			t.Errorf("expected field error for %s, got %v", want, cfgErr.Fields)
+		}
+	}
+}
+
+func TestLoadConfigFromMap_Lenient(t *testing.T) {
+	var raw map[string]interface{}
+	err := json.Unmarshal([]byte(`{
+		"preset": "classic",
+		"roles": ["merlin", "assassin"],
+		"max_rejections": 4,
+		"jester_mode": true,
+		"modules": {"excalibur": true}
+	}`), &raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseConfig(raw); err == nil {
+		t.Error("expected ParseConfig to reject unknown fields")
+	}
+	cfg, err := LoadConfigFromMap(raw)
+	if cfgErr, ok := err.(*ConfigError); !ok || len(cfgErr.Fields) != 2 {
+		t.Errorf("expected the two unknown fields reported, got %v", err)
+	}
+	if len(cfg.Roles) != 2 || cfg.MaxRejections != 4 {
+		t.Errorf("expected the valid fields applied, got %+v", cfg)
+	}
+}
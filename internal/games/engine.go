@@ -2,9 +2,16 @@ package games
 
 import (
 	"context"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"math/rand"
+	"strconv"
 	"time"
 
 	"github.com/vntrieu/avalon/internal/store"
@@ -21,40 +28,79 @@ type ApplyMoveResult struct {
 type BroadcastEvent struct {
 	Event   string                 `json:"event"`
 	Payload map[string]interface{} `json:"payload"`
+	// To, when set, makes the event private: only this room_player_id receives it.
+	To string `json:"to,omitempty"`
 }
 
 // GameStore interface for persistence (avoid circular import; implemented by store.GameStore + GameEventStore).
+// GetLatestSnapshot must report the snapshot row's version as "version"; CommitMove checks it to detect concurrent moves.
 type GameStore interface {
 	GetLatestSnapshot(ctx context.Context, gameID string) (map[string]interface{}, error)
-	CreateOrUpdateSnapshot(ctx context.Context, gameID string, stateJSON map[string]interface{}) (int32, error)
-	UpdateGameStatus(ctx context.Context, gameID string, status string, endedAt *time.Time) error
+	// GetSnapshotAtVersion returns the snapshot written at version, or nil if there is none (for sync_state deltas).
+	GetSnapshotAtVersion(ctx context.Context, gameID string, version int32) (map[string]interface{}, error)
 	GetGamePlayerIDsInOrder(ctx context.Context, gameID string) ([]string, error)
+	GetGameConfig(ctx context.Context, gameID string) (map[string]interface{}, error)
+	// GetGameHostID returns the room host's room_player_id; only the host may send host actions (see IsHostAction).
+	GetGameHostID(ctx context.Context, gameID string) (string, error)
+	// IsGameRoomMember reports whether roomPlayerID is in the game's room (a replace_player replacement must be).
+	IsGameRoomMember(ctx context.Context, gameID string, roomPlayerID string) (bool, error)
+	// SeatSpectator returns the room_player_id a spectator (by user ID) takes a seat under, creating their room
+	// player if needed; "" if userID is not a user. store.ErrDisplayNameTaken if their name is taken in the room.
+	SeatSpectator(ctx context.Context, gameID string, userID string) (string, error)
+	// CommitMove atomically appends the event and writes snapshot, roles and status; store.ErrStaleState on a version conflict.
+	CommitMove(ctx context.Context, gameID string, commit store.MoveCommit) (int32, error)
 }
 
-// GameEventStore interface for appending events.
+// GameEventStore interface for reading the move log (moves are appended by GameStore.CommitMove).
 type GameEventStore interface {
-	CreateGameEvent(ctx context.Context, req store.CreateGameEventRequest) (*store.GameEvent, error)
+	GetGameEvents(ctx context.Context, gameID string) ([]store.GameEvent, error)
 }
 
 // Engine applies moves and drives phase transitions.
+// Each game's rules come from its config_json; config is used for games without one.
 type Engine struct {
 	store   GameStore
 	events  GameEventStore
 	config  RulesConfig
+	seeds   SeedSource
+	now     func() time.Time
 }
 
-// NewEngine creates an engine with the given stores and config.
+// SeedSource returns the seed for a new game's role deal. The deal itself is a math/rand shuffle of that seed,
+// so a game can be replayed and its deal checked once the seed is revealed.
+type SeedSource func() (int64, error)
+
+// SecureSeed is the default SeedSource: a seed from crypto/rand, so players cannot predict the deal.
+func SecureSeed() (int64, error) {
+	var b [8]byte
+	if _, err := cryptorand.Read(b[:]); err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(b[:]) &^ (1 << 63)), nil
+}
+
+// NewEngine creates an engine with the given stores and default config.
 func NewEngine(store GameStore, events GameEventStore, config RulesConfig) *Engine {
 	if config.Phases == nil {
 		config = ClassicAvalonConfig()
 	}
-	if config.TeamSizes == nil {
-		config.TeamSizes = []int{2, 3, 2, 3, 3}
-	}
 	if config.FailThreshold <= 0 {
 		config.FailThreshold = 3
 	}
-	return &Engine{store: store, events: events, config: config}
+	if config.MaxRejections <= 0 {
+		config.MaxRejections = 5
+	}
+	return &Engine{store: store, events: events, config: config, seeds: SecureSeed, now: time.Now}
+}
+
+// SetSeedSource replaces the engine's SeedSource (e.g. a fixed seed in tests to pin role layouts).
+func (e *Engine) SetSeedSource(src SeedSource) {
+	e.seeds = src
+}
+
+// SetClock replaces the clock used for phase deadlines (e.g. a fixed time in tests).
+func (e *Engine) SetClock(now func() time.Time) {
+	e.now = now
 }
 
 // GetState loads the latest snapshot for the game and returns a GameState. If no snapshot, returns nil.
@@ -69,27 +115,82 @@ func (e *Engine) GetState(ctx context.Context, gameID string) (*GameState, error
 	return StateFromMap(m), nil
 }
 
+// rulesFor returns the game's rules parsed from its config_json, or the engine config when the game has none.
+func (e *Engine) rulesFor(ctx context.Context, gameID string) (RulesConfig, error) {
+	raw, err := e.store.GetGameConfig(ctx, gameID)
+	if err != nil {
+		return RulesConfig{}, fmt.Errorf("get game config: %w", err)
+	}
+	if len(raw) == 0 {
+		return e.config, nil
+	}
+	// Stored configs are loaded leniently: a game must stay playable even if its config no longer validates.
+	rules, err := LoadConfigFromMap(raw)
+	if err != nil {
+		log.Printf("game %s: ignoring stored config fields: %v", gameID, err)
+	}
+	return rules, nil
+}
+
+// maxMoveAttempts bounds how often ApplyMove recomputes a move that lost a race with another move on the same game.
+const maxMoveAttempts = 3
+
 // ApplyMove validates the move, applies it, persists event + snapshot, updates game status if finished.
 // moveType is "vote" or "action"; for "action", payload should contain "action": "<action_type>" and action-specific fields.
+// Moves on one game are serialized by CommitMove; a move computed from an outdated snapshot is re-validated
+// against the new state and retried, and reported as store.ErrStaleState if it keeps losing.
 func (e *Engine) ApplyMove(ctx context.Context, gameID string, roomPlayerID string, moveType string, payload map[string]interface{}) ApplyMoveResult {
+	var result ApplyMoveResult
+	for attempt := 0; attempt < maxMoveAttempts; attempt++ {
+		result = e.applyMoveOnce(ctx, gameID, roomPlayerID, moveType, payload)
+		if !errors.Is(result.Error, store.ErrStaleState) {
+			return result
+		}
+	}
+	return ApplyMoveResult{Error: fmt.Errorf("%w: the game changed while your move was applied; sync_state and retry", store.ErrStaleState)}
+}
+
+func (e *Engine) applyMoveOnce(ctx context.Context, gameID string, roomPlayerID string, moveType string, payload map[string]interface{}) ApplyMoveResult {
 	state, err := e.GetState(ctx, gameID)
 	if err != nil {
 		return ApplyMoveResult{Error: fmt.Errorf("get state: %w", err)}
 	}
+	rules, err := e.rulesFor(ctx, gameID)
+	if err != nil {
+		return ApplyMoveResult{Error: err}
+	}
 	// No snapshot or lobby without players: only allow start_game (bootstrap from DB players).
 	if state == nil || (state.Phase == PhaseLobby && len(state.PlayerIDs) == 0) {
 		if moveType != "action" {
-			return ApplyMoveResult{Error: fmt.Errorf("game not started; use action start_game")}
+			return ApplyMoveResult{Error: moveError(CodeGameNotStarted, nil, "game not started; use action start_game")}
 		}
 		action, _ := payload["action"].(string)
 		if action != ActionStartGame {
-			return ApplyMoveResult{Error: fmt.Errorf("only start_game allowed in lobby")}
+			return ApplyMoveResult{Error: wrongPhase(PhaseLobby, "only start_game allowed in lobby")}
+		}
+		var expectedVersion int32
+		if state != nil {
+			expectedVersion = int32(state.Version)
 		}
-		return e.bootstrapAndStart(ctx, gameID, roomPlayerID, payload)
+		return e.bootstrapAndStart(ctx, rules, gameID, roomPlayerID, expectedVersion)
 	}
 
 	if state.Status == "finished" {
-		return ApplyMoveResult{Error: fmt.Errorf("game already finished")}
+		return ApplyMoveResult{Error: moveError(CodeGameFinished, nil, "game already finished")}
+	}
+	if action, _ := payload["action"].(string); moveType == "action" && IsHostAction(action) {
+		hostID, err := e.store.GetGameHostID(ctx, gameID)
+		if err != nil {
+			return ApplyMoveResult{Error: fmt.Errorf("get host: %w", err)}
+		}
+		if hostID != roomPlayerID {
+			return ApplyMoveResult{Error: moveError(CodeNotHost, map[string]interface{}{"action": action}, "only the host can %s", action)}
+		}
+		if action == ActionReplacePlayer || action == ActionFlagSeat {
+			if err := e.checkSeatAction(ctx, gameID, state, action, payload); err != nil {
+				return ApplyMoveResult{Error: err}
+			}
+		}
 	}
 
 	var next *GameState
@@ -97,11 +198,11 @@ func (e *Engine) ApplyMove(ctx context.Context, gameID string, roomPlayerID stri
 
 	switch moveType {
 	case "vote":
-		next, events, err = e.applyVote(ctx, state, roomPlayerID, payload)
+		next, events, err = e.applyVote(ctx, rules, state, roomPlayerID, payload)
 	case "action":
-		next, events, err = e.applyAction(ctx, state, roomPlayerID, payload)
+		next, events, err = e.applyAction(ctx, rules, state, roomPlayerID, payload)
 	default:
-		return ApplyMoveResult{Error: fmt.Errorf("unknown move type %q", moveType)}
+		return ApplyMoveResult{Error: moveError(CodeUnknownMove, map[string]interface{}{"move_type": moveType}, "unknown move type %q", moveType)}
 	}
 	if err != nil {
 		return ApplyMoveResult{Error: err}
@@ -110,100 +211,168 @@ func (e *Engine) ApplyMove(ctx context.Context, gameID string, roomPlayerID stri
 		return ApplyMoveResult{Error: fmt.Errorf("no state update")}
 	}
 
-	// Persist: append event, write snapshot, update game status if finished
-	eventPayload := payload
-	if eventPayload == nil {
-		eventPayload = make(map[string]interface{})
+	return e.commitMove(ctx, rules, gameID, &roomPlayerID, moveType, payload, state, next, events)
+}
+
+// commitMove persists next as the move after state in one transaction: it appends the move's event (payload plus
+// move_type), writes the snapshot with the phase deadline and updates the game status if it changed.
+// roomPlayerID is nil for moves the server makes (e.g. timeouts).
+func (e *Engine) commitMove(ctx context.Context, rules RulesConfig, gameID string, roomPlayerID *string, moveType string, payload map[string]interface{}, state, next *GameState, events []BroadcastEvent) ApplyMoveResult {
+	eventPayload := make(map[string]interface{}, len(payload)+1)
+	for k, v := range payload {
+		eventPayload[k] = v
 	}
 	eventPayload["move_type"] = moveType
-	_, err = e.events.CreateGameEvent(ctx, store.CreateGameEventRequest{
-		GameID:       gameID,
-		RoomPlayerID: &roomPlayerID,
-		Type:         moveType,
-		Payload:      eventPayload,
-	})
-	if err != nil {
-		return ApplyMoveResult{Error: fmt.Errorf("persist event: %w", err)}
+	now := e.now()
+	scheduleDeadline(rules, state, next, now)
+	next.Version = state.Version + 1
+	commit := store.MoveCommit{
+		ExpectedVersion: int32(state.Version),
+		Event: &store.CreateGameEventRequest{
+			GameID:       gameID,
+			RoomPlayerID: roomPlayerID,
+			Type:         moveType,
+			Payload:      eventPayload,
+		},
+		State: next.ToMap(),
+		Seats: seatChanges(state, next),
 	}
-
-	stateMap := next.ToMap()
-	version, err := e.store.CreateOrUpdateSnapshot(ctx, gameID, stateMap)
+	if next.Status == "finished" {
+		commit.Status = "finished"
+		commit.EndedAt = &now
+	} else if next.Status != state.Status {
+		commit.Status = next.Status
+	}
+	version, err := e.store.CommitMove(ctx, gameID, commit)
 	if err != nil {
-		return ApplyMoveResult{Error: fmt.Errorf("persist snapshot: %w", err)}
+		return ApplyMoveResult{Error: fmt.Errorf("commit move: %w", err)}
 	}
 	next.Version = int(version)
 
-	if next.Status == "finished" {
-		now := time.Now()
-		_ = e.store.UpdateGameStatus(ctx, gameID, "finished", &now)
-	}
-
 	return ApplyMoveResult{State: next, Events: events}
 }
 
 // bootstrapAndStart builds initial state from DB (player list) and transitions to team_selection.
-func (e *Engine) bootstrapAndStart(ctx context.Context, gameID string, roomPlayerID string, payload map[string]interface{}) ApplyMoveResult {
+// expectedVersion is the lobby snapshot's version (0 if none), so only one concurrent start_game commits.
+func (e *Engine) bootstrapAndStart(ctx context.Context, rules RulesConfig, gameID string, roomPlayerID string, expectedVersion int32) ApplyMoveResult {
 	playerIDs, err := e.store.GetGamePlayerIDsInOrder(ctx, gameID)
 	if err != nil {
 		return ApplyMoveResult{Error: fmt.Errorf("get players: %w", err)}
 	}
-	n := len(playerIDs)
-	if n < e.config.MinPlayers || n > e.config.MaxPlayers {
-		return ApplyMoveResult{Error: fmt.Errorf("player count %d not in range [%d,%d]", n, e.config.MinPlayers, e.config.MaxPlayers)}
+	seed, err := e.seeds()
+	if err != nil {
+		return ApplyMoveResult{Error: fmt.Errorf("seed: %w", err)}
 	}
-
-	// Assign simple roles: 2 evils for 5–6, 3 for 7+ (classic).
-	roles := make(map[string]string)
-	evilCount := 2
-	if n >= 7 {
-		evilCount = 3
+	state, err := newGameState(rules, gameID, playerIDs, seed)
+	if err != nil {
+		return ApplyMoveResult{Error: err}
 	}
-	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
-	order := rng.Perm(n)
-	for i := 0; i < evilCount; i++ {
-		roles[playerIDs[order[i]]] = "evil"
+	scheduleDeadline(rules, nil, state, e.now())
+	state.Version = int(expectedVersion) + 1
+	version, err := e.store.CommitMove(ctx, gameID, store.MoveCommit{
+		ExpectedVersion: expectedVersion,
+		Event: &store.CreateGameEventRequest{
+			GameID:       gameID,
+			RoomPlayerID: &roomPlayerID,
+			Type:         "action",
+			// The seed and seating are logged so Replay can rebuild the same deal.
+			Payload: map[string]interface{}{
+				"action": ActionStartGame, "move_type": "action",
+				"seed": strconv.FormatInt(seed, 10), "player_ids": playerIDs,
+			},
+		},
+		State:  state.ToMap(),
+		Roles:  state.Roles,
+		Status: "in_progress",
+	})
+	if err != nil {
+		return ApplyMoveResult{Error: fmt.Errorf("start game: %w", err)}
+	}
+	state.Version = int(version)
+
+	ev := BroadcastEvent{Event: "game_started", Payload: map[string]interface{}{
+		"phase": state.Phase, "round_index": state.RoundIndex, "leader_id": state.LeaderPlayerID(),
+		"seed_hash": state.SeedHash,
+	}}
+	if state.LadyHolder != "" {
+		ev.Payload["lady_holder"] = state.LadyHolder
 	}
+	events := []BroadcastEvent{ev}
+	// Each player privately learns their role and what it reveals at night.
 	for _, id := range playerIDs {
-		if roles[id] == "" {
-			roles[id] = "good"
-		}
+		events = append(events, BroadcastEvent{Event: "role_assigned", To: id, Payload: RoleBriefing(state, id)})
 	}
+	return ApplyMoveResult{State: state, Events: events}
+}
 
-	teamSizes := e.config.TeamSizes
+// SeedHash returns the hex SHA-256 of the decimal seed. It is published when the game starts so players can
+// check, once the seed is revealed at the end, that the deal was fixed in advance.
+func SeedHash(seed int64) string {
+	sum := sha256.Sum256([]byte(strconv.FormatInt(seed, 10)))
+	return hex.EncodeToString(sum[:])
+}
+
+// newGameState deals roles with a math/rand source seeded by seed and returns the team_selection state for
+// round 1. The same rules, players and seed always produce the same state.
+func newGameState(rules RulesConfig, gameID string, playerIDs []string, seed int64) (*GameState, error) {
+	n := len(playerIDs)
+	if n < rules.MinPlayers || n > rules.MaxPlayers {
+		return nil, moveError(CodeInvalidPlayerCount, map[string]interface{}{"count": n, "min": rules.MinPlayers, "max": rules.MaxPlayers},
+			"player count %d not in range [%d,%d]", n, rules.MinPlayers, rules.MaxPlayers)
+	}
+
+	roles, err := AssignRoles(playerIDs, rules.Roles, rand.New(rand.NewSource(seed)))
+	if err != nil {
+		return nil, moveError(CodeInvalidConfig, nil, "assign roles: %v", err)
+	}
+
+	// Team sizes: host table from the game config, otherwise classic defaults for n players.
+	teamSizes := rules.TeamSizes
 	if len(teamSizes) == 0 {
 		teamSizes = DefaultTeamSizesForPlayerCount(n)
 	}
+	if err := ValidateTeamSizes(teamSizes, n); err != nil {
+		return nil, moveError(CodeInvalidConfig, map[string]interface{}{"field": "team_sizes"}, "%v", err)
+	}
+	// Fails required: the host table or the classic one, checked against the team sizes in play.
+	failsTable := rules.FailsRequired
+	if len(failsTable) == 0 {
+		failsTable = DefaultFailsRequiredForPlayerCount(n)
+	}
+	for i, fails := range failsTable {
+		if i < len(teamSizes) && fails > teamSizes[i] {
+			return nil, moveError(CodeInvalidConfig, map[string]interface{}{"field": "fails_required"},
+				"fails_required[%d] = %d exceeds team size %d", i, fails, teamSizes[i])
+		}
+	}
 
 	state := &GameState{
-		GameID:       gameID,
-		Phase:        PhaseTeamSelection,
-		Status:       "in_progress",
-		RoundIndex:   1,
-		LeaderIndex:  0,
-		PlayerIDs:    playerIDs,
-		Roles:        roles,
+		GameID:         gameID,
+		Phase:          PhaseTeamSelection,
+		Status:         "in_progress",
+		RoundIndex:     1,
+		LeaderIndex:    0,
+		PlayerIDs:      playerIDs,
+		Roles:          roles,
+		TeamSizes:      teamSizes,
 		MissionResults: []string{},
+		Seed:           seed,
+		SeedHash:       SeedHash(seed),
 	}
-	stateMap := state.ToMap()
-	stateMap["team_sizes"] = teamSizes
-	version, err := e.store.CreateOrUpdateSnapshot(ctx, gameID, stateMap)
-	if err != nil {
-		return ApplyMoveResult{Error: fmt.Errorf("create initial snapshot: %w", err)}
-	}
-	state.Version = int(version)
-	if err := e.store.UpdateGameStatus(ctx, gameID, "in_progress", nil); err != nil {
-		return ApplyMoveResult{Error: fmt.Errorf("update game status: %w", err)}
+	if rules.Modules.LadyOfTheLake {
+		// The token starts with the player seated before the first leader.
+		state.LadyHolder = playerIDs[n-1]
+		state.LadyHolders = []string{state.LadyHolder}
 	}
-
-	ev := BroadcastEvent{Event: "game_started", Payload: map[string]interface{}{
-		"phase": state.Phase, "round_index": state.RoundIndex, "leader_id": state.LeaderPlayerID(),
-	}}
-	return ApplyMoveResult{State: state, Events: []BroadcastEvent{ev}}
+	return state, nil
 }
 
-func (e *Engine) applyVote(ctx context.Context, state *GameState, roomPlayerID string, payload map[string]interface{}) (*GameState, []BroadcastEvent, error) {
+func (e *Engine) applyVote(ctx context.Context, rules RulesConfig, state *GameState, roomPlayerID string, payload map[string]interface{}) (*GameState, []BroadcastEvent, error) {
+	if state.Status == "paused" {
+		return nil, nil, moveError(CodeGamePaused, nil, "game is paused")
+	}
 	if !e.isPlayerInGame(state, roomPlayerID) {
-		return nil, nil, fmt.Errorf("player not in game")
+		return nil, nil, moveError(CodeNotInGame, nil, "player not in game")
 	}
 
 	switch state.Phase {
@@ -213,7 +382,7 @@ func (e *Engine) applyVote(ctx context.Context, state *GameState, roomPlayerID s
 			if s, ok := payload["approved"].(string); ok && (s == "true" || s == "false") {
 				approved = s == "true"
 			} else {
-				return nil, nil, fmt.Errorf("payload must include approved: true/false")
+				return nil, nil, missingField("approved", "payload must include approved: true/false")
 			}
 		}
 		next := state.Clone()
@@ -221,7 +390,7 @@ func (e *Engine) applyVote(ctx context.Context, state *GameState, roomPlayerID s
 			next.TeamVotes = make(map[string]string)
 		}
 		if _, exists := next.TeamVotes[roomPlayerID]; exists {
-			return nil, nil, fmt.Errorf("already voted")
+			return nil, nil, moveError(CodeAlreadyVoted, map[string]interface{}{"phase": state.Phase}, "already voted")
 		}
 		v := "reject"
 		if approved {
@@ -236,22 +405,39 @@ func (e *Engine) applyVote(ctx context.Context, state *GameState, roomPlayerID s
 					approveCount++
 				}
 			}
-			if approveCount > len(next.PlayerIDs)/2 {
+			approved := approveCount > len(next.PlayerIDs)/2
+			// Ballots stay hidden until the last vote, then every vote is revealed at once.
+			reveal := BroadcastEvent{Event: "team_vote_revealed", Payload: map[string]interface{}{
+				"votes": next.TeamVotes, "approve_count": approveCount, "reject_count": len(next.TeamVotes) - approveCount,
+				"approved": approved}}
+			if approved {
 				// Team approved -> mission_vote
 				next.Phase = PhaseMissionVote
 				next.TeamVotes = nil
+				next.RejectCount = 0
 				ev := BroadcastEvent{Event: "team_approved", Payload: map[string]interface{}{"phase": next.Phase}}
-				return next, []BroadcastEvent{ev}, nil
+				return next, []BroadcastEvent{reveal, ev}, nil
 			}
 			// Rejected -> next leader, back to team_selection
-				next.RejectCount++
-				next.Phase = PhaseTeamSelection
-				next.LeaderIndex = (next.LeaderIndex + 1) % len(next.PlayerIDs)
+			next.RejectCount++
+			if next.RejectCount >= rules.MaxRejections {
+				next.Status = "finished"
+				next.Phase = PhaseFinished
+				next.Winner = "evil"
 				next.ProposedTeam = nil
 				next.TeamVotes = nil
-				ev := BroadcastEvent{Event: "team_rejected", Payload: map[string]interface{}{
-					"phase": next.Phase, "reject_count": next.RejectCount, "leader_id": next.LeaderPlayerID()}}
-				return next, []BroadcastEvent{ev}, nil
+				ev := BroadcastEvent{Event: "game_ended", Payload: map[string]interface{}{
+					"winner": next.Winner, "reason": "too_many_rejections", "reject_count": next.RejectCount}}
+				return next, []BroadcastEvent{reveal, ev}, nil
+			}
+			next.Phase = PhaseTeamSelection
+			next.LeaderIndex = (next.LeaderIndex + 1) % len(next.PlayerIDs)
+			next.ProposedTeam = nil
+			next.TeamVotes = nil
+			ev := BroadcastEvent{Event: "team_rejected", Payload: map[string]interface{}{
+				"phase": next.Phase, "reject_count": next.RejectCount, "rejections_left": rules.MaxRejections - next.RejectCount,
+				"leader_id": next.LeaderPlayerID()}}
+			return next, []BroadcastEvent{reveal, ev}, nil
 		}
 		return next, []BroadcastEvent{{Event: "vote_recorded", Payload: map[string]interface{}{"player_id": roomPlayerID}}}, nil
 
@@ -261,18 +447,18 @@ func (e *Engine) applyVote(ctx context.Context, state *GameState, roomPlayerID s
 			if s, ok := payload["success"].(string); ok && (s == "true" || s == "false") {
 				success = s == "true"
 			} else {
-				return nil, nil, fmt.Errorf("payload must include success: true/false for mission vote")
+				return nil, nil, missingField("success", "payload must include success: true/false for mission vote")
 			}
 		}
 		if !e.isOnProposedTeam(state, roomPlayerID) {
-			return nil, nil, fmt.Errorf("only team members can submit mission vote")
+			return nil, nil, moveError(CodeNotOnTeam, nil, "only team members can submit mission vote")
 		}
 		next := state.Clone()
 		if next.MissionVotes == nil {
 			next.MissionVotes = make(map[string]string)
 		}
 		if _, exists := next.MissionVotes[roomPlayerID]; exists {
-			return nil, nil, fmt.Errorf("already voted")
+			return nil, nil, moveError(CodeAlreadyVoted, map[string]interface{}{"phase": state.Phase}, "already voted")
 		}
 		if success {
 			next.MissionVotes[roomPlayerID] = "success"
@@ -281,15 +467,18 @@ func (e *Engine) applyVote(ctx context.Context, state *GameState, roomPlayerID s
 		}
 		teamSize := len(state.ProposedTeam)
 		if len(next.MissionVotes) >= teamSize {
-			// Resolution: any fail -> mission fail
+			// Resolution: mission fails when fail cards reach the round's requirement.
+			// Only the totals leave the engine; cards are never tied to a player.
 			failCount := 0
 			for _, v := range next.MissionVotes {
 				if v == "fail" {
 					failCount++
 				}
 			}
+			successCount := len(next.MissionVotes) - failCount
+			failsRequired := failsRequired(rules, state)
 			result := "success"
-			if failCount > 0 {
+			if failCount >= failsRequired {
 				result = "fail"
 			}
 			next.MissionResults = append(next.MissionResults, result)
@@ -308,40 +497,60 @@ func (e *Engine) applyVote(ctx context.Context, state *GameState, roomPlayerID s
 				}
 			}
 			successTotal := len(next.MissionResults) - failTotal
-			if failTotal >= e.config.FailThreshold {
+			if failTotal >= rules.FailThreshold {
 				next.Status = "finished"
 				next.Phase = PhaseFinished
 				next.Winner = "evil"
-				ev := BroadcastEvent{Event: "game_ended", Payload: map[string]interface{}{"winner": next.Winner, "mission_result": result}}
+				ev := BroadcastEvent{Event: "game_ended", Payload: map[string]interface{}{
+					"winner": next.Winner, "mission_result": result, "fail_count": failCount, "success_count": successCount, "fails_required": failsRequired}}
+				return next, []BroadcastEvent{ev}, nil
+			}
+			if successTotal >= 3 && next.PlayerWithRole(RoleAssassin) != "" && next.PlayerWithRole(RoleMerlin) != "" {
+				// Evil gets one chance to name Merlin before good wins.
+				next.Phase = PhaseAssassination
+				ev := BroadcastEvent{Event: "assassination_started", Payload: map[string]interface{}{
+					"mission_result": result, "fail_count": failCount, "success_count": successCount, "fails_required": failsRequired, "phase": next.Phase}}
 				return next, []BroadcastEvent{ev}, nil
 			}
 			if successTotal >= 3 {
 				next.Status = "finished"
 				next.Phase = PhaseFinished
 				next.Winner = "good"
-				ev := BroadcastEvent{Event: "game_ended", Payload: map[string]interface{}{"winner": next.Winner, "mission_result": result}}
+				ev := BroadcastEvent{Event: "game_ended", Payload: map[string]interface{}{
+					"winner": next.Winner, "mission_result": result, "fail_count": failCount, "success_count": successCount, "fails_required": failsRequired}}
 				return next, []BroadcastEvent{ev}, nil
 			}
+			if rules.Modules.LadyOfTheLake && next.LadyHolder != "" && LadyOfTheLakeAfterRound(state.RoundIndex) {
+				// Lady of the Lake is used before the next leader proposes.
+				next.Phase = PhaseLadyOfTheLake
+			}
 			ev := BroadcastEvent{Event: "mission_resolved", Payload: map[string]interface{}{
-				"result": result, "round_index": next.RoundIndex, "leader_id": next.LeaderPlayerID(), "phase": next.Phase}}
+				"result": result, "fail_count": failCount, "success_count": successCount, "fails_required": failsRequired,
+				"round_index": next.RoundIndex, "leader_id": next.LeaderPlayerID(), "phase": next.Phase}}
 			return next, []BroadcastEvent{ev}, nil
 		}
 		return next, []BroadcastEvent{{Event: "vote_recorded", Payload: map[string]interface{}{"player_id": roomPlayerID}}}, nil
 	}
 
-	return nil, nil, fmt.Errorf("vote not allowed in phase %s", state.Phase)
+	return nil, nil, wrongPhase(state.Phase, "vote not allowed in phase %s", state.Phase)
 }
 
-func (e *Engine) applyAction(ctx context.Context, state *GameState, roomPlayerID string, payload map[string]interface{}) (*GameState, []BroadcastEvent, error) {
+func (e *Engine) applyAction(ctx context.Context, rules RulesConfig, state *GameState, roomPlayerID string, payload map[string]interface{}) (*GameState, []BroadcastEvent, error) {
 	action, _ := payload["action"].(string)
 	if action == "" {
 		action, _ = payload["type"].(string)
 	}
 	if action == "" {
-		return nil, nil, fmt.Errorf("payload must include action or type")
+		return nil, nil, missingField("action", "payload must include action or type")
+	}
+	if IsHostAction(action) {
+		return e.applyHostAction(state, roomPlayerID, action, payload)
+	}
+	if state.Status == "paused" {
+		return nil, nil, moveError(CodeGamePaused, nil, "game is paused")
 	}
 
-	allowed := e.getAllowedActions(state.Phase)
+	allowed := getAllowedActions(rules, state.Phase)
 	ok := false
 	for _, a := range allowed {
 		if a == action {
@@ -350,25 +559,26 @@ func (e *Engine) applyAction(ctx context.Context, state *GameState, roomPlayerID
 		}
 	}
 	if !ok {
-		return nil, nil, fmt.Errorf("action %q not allowed in phase %s", action, state.Phase)
+		return nil, nil, moveError(CodeWrongPhase, map[string]interface{}{"phase": state.Phase, "action": action, "allowed_actions": allowed},
+			"action %q not allowed in phase %s", action, state.Phase)
 	}
 
 	switch action {
 	case ActionStartGame:
 		// Handled in bootstrapAndStart when state is nil
-		return nil, nil, fmt.Errorf("game already started")
+		return nil, nil, wrongPhase(state.Phase, "game already started")
 	case ActionProposeTeam:
 		if state.LeaderPlayerID() != roomPlayerID {
-			return nil, nil, fmt.Errorf("only the leader can propose a team")
+			return nil, nil, moveError(CodeNotLeader, map[string]interface{}{"leader_id": state.LeaderPlayerID()}, "only the leader can propose a team")
 		}
 		team, ok := stringSliceFromPayload(payload["team_ids"])
 		if !ok {
 			team, ok = stringSliceFromPayload(payload["team"])
 			if !ok {
-				return nil, nil, fmt.Errorf("payload must include team_ids or team (array of room_player_id)")
+				return nil, nil, missingField("team_ids", "payload must include team_ids or team (array of room_player_id)")
 			}
 		}
-		teamSizes := e.config.TeamSizes
+		teamSizes := state.TeamSizes
 		if len(teamSizes) == 0 {
 			teamSizes = DefaultTeamSizesForPlayerCount(len(state.PlayerIDs))
 		}
@@ -378,11 +588,12 @@ func (e *Engine) applyAction(ctx context.Context, state *GameState, roomPlayerID
 		}
 		requiredSize := teamSizes[roundIdx-1]
 		if len(team) != requiredSize {
-			return nil, nil, fmt.Errorf("team must have exactly %d members for this round", requiredSize)
+			return nil, nil, moveError(CodeInvalidTeamSize, map[string]interface{}{"required": requiredSize, "got": len(team), "round_index": roundIdx},
+				"team must have exactly %d members for this round", requiredSize)
 		}
 		for _, id := range team {
 			if !e.isPlayerInGame(state, id) {
-				return nil, nil, fmt.Errorf("team includes non-player %s", id)
+				return nil, nil, moveError(CodeInvalidTarget, map[string]interface{}{"target_id": id}, "team includes non-player %s", id)
 			}
 		}
 		next := state.Clone()
@@ -391,13 +602,77 @@ func (e *Engine) applyAction(ctx context.Context, state *GameState, roomPlayerID
 		next.TeamVotes = make(map[string]string)
 		ev := BroadcastEvent{Event: "team_proposed", Payload: map[string]interface{}{"team": team, "phase": next.Phase}}
 		return next, []BroadcastEvent{ev}, nil
-	}
+	case ActionAssassinate:
+		if state.Roles[roomPlayerID] != RoleAssassin {
+			return nil, nil, moveError(CodeNotAssassin, nil, "only the assassin can assassinate")
+		}
+		target, _ := payload["target_id"].(string)
+		if target == "" {
+			return nil, nil, missingField("target_id", "payload must include target_id (room_player_id)")
+		}
+		if !e.isPlayerInGame(state, target) {
+			return nil, nil, moveError(CodeInvalidTarget, map[string]interface{}{"target_id": target}, "target is not a player in this game")
+		}
+		if target == roomPlayerID {
+			return nil, nil, moveError(CodeInvalidTarget, map[string]interface{}{"target_id": target}, "assassin cannot target themselves")
+		}
+		next := state.Clone()
+		next.AssassinTarget = target
+		next.Status = "finished"
+		next.Phase = PhaseFinished
+		hit := state.Roles[target] == RoleMerlin
+		next.Winner = "good"
+		if hit {
+			next.Winner = "evil"
+		}
+		ev := BroadcastEvent{Event: "game_ended", Payload: map[string]interface{}{
+			"winner": next.Winner, "target_id": target, "merlin_assassinated": hit}}
+		return next, []BroadcastEvent{ev}, nil
+	case ActionInspect:
+		if state.LadyHolder != roomPlayerID {
+			return nil, nil, moveError(CodeNotLadyHolder, map[string]interface{}{"lady_holder": state.LadyHolder}, "only the Lady of the Lake holder can inspect")
+		}
+		target, _ := payload["target_id"].(string)
+		if target == "" {
+			return nil, nil, missingField("target_id", "payload must include target_id (room_player_id)")
+		}
+		if !e.isPlayerInGame(state, target) {
+			return nil, nil, moveError(CodeInvalidTarget, map[string]interface{}{"target_id": target}, "target is not a player in this game")
+		}
+		if state.HasHeldLady(target) {
+			return nil, nil, moveError(CodeInvalidTarget, map[string]interface{}{"target_id": target}, "target has already held the Lady of the Lake")
+		}
+		next := state.Clone()
+		next.LadyHolder = target
+		next.LadyHolders = append(next.LadyHolders, target)
+		next.Phase = PhaseTeamSelection
+		// Everyone sees who was inspected; only the inspector learns the alignment.
+		public := BroadcastEvent{Event: "lady_inspected", Payload: map[string]interface{}{
+			"inspector_id": roomPlayerID, "target_id": target, "lady_holder": next.LadyHolder,
+			"phase": next.Phase, "round_index": next.RoundIndex, "leader_id": next.LeaderPlayerID()}}
+		private := BroadcastEvent{Event: "lady_result", To: roomPlayerID, Payload: map[string]interface{}{
+			"target_id": target, "alignment": AlignmentOf(state.Roles[target])}}
+		return next, []BroadcastEvent{public, private}, nil
+	}
+
+	return nil, nil, moveError(CodeUnknownMove, map[string]interface{}{"action": action}, "action %q not implemented", action)
+}
 
-	return nil, nil, fmt.Errorf("action %q not implemented", action)
+// failsRequired returns the fail cards needed to fail the current round's mission.
+func failsRequired(rules RulesConfig, state *GameState) int {
+	table := rules.FailsRequired
+	if len(table) == 0 {
+		table = DefaultFailsRequiredForPlayerCount(len(state.PlayerIDs))
+	}
+	roundIdx := state.RoundIndex
+	if roundIdx <= 0 || roundIdx > len(table) {
+		return 1
+	}
+	return table[roundIdx-1]
 }
 
-func (e *Engine) getAllowedActions(phase string) []string {
-	for _, p := range e.config.Phases {
+func getAllowedActions(rules RulesConfig, phase string) []string {
+	for _, p := range rules.Phases {
 		if p.Name == phase {
 			return p.AllowedActions
 		}
@@ -440,28 +715,6 @@ func stringSliceFromPayload(v interface{}) ([]string, bool) {
 	}
 }
 
-// LoadConfigFromMap loads RulesConfig from game config_json (e.g. from DB). Falls back to ClassicAvalonConfig.
-func LoadConfigFromMap(configJSON map[string]interface{}) RulesConfig {
-	if configJSON == nil {
-		return ClassicAvalonConfig()
-	}
-	// Optional: parse preset name or full phases from config
-	if preset, ok := configJSON["preset"].(string); ok && preset == "classic" {
-		return ClassicAvalonConfig()
-	}
-	return ClassicAvalonConfig()
-}
-
-// StateToMapForSync serializes state for sync_state response (same as ToMap but ensures JSON-safe).
-func StateToMapForSync(state *GameState) (map[string]interface{}, error) {
-	if state == nil {
-		return map[string]interface{}{}, nil
-	}
-	m := state.ToMap()
-	// Remove or mask roles if not revealed per rules
-	return m, nil
-}
-
 // DecodePayload ensures payload is map[string]interface{} (from JSON).
 func DecodePayload(raw interface{}) map[string]interface{} {
 	if raw == nil {
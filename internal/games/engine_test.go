@@ -2,21 +2,24 @@ package games
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
 	"testing"
-	"time"
 
 	"github.com/vntrieu/avalon/internal/store"
 )
 
 func TestStateFromMap_ToMap_RoundTrip(t *testing.T) {
 	s := &GameState{
-		GameID:       "game-1",
-		Phase:        PhaseTeamSelection,
-		Status:       "in_progress",
-		RoundIndex:   1,
-		LeaderIndex:  0,
-		PlayerIDs:    []string{"p1", "p2", "p3"},
-		RejectCount:  0,
+		GameID:      "game-1",
+		Phase:       PhaseTeamSelection,
+		Status:      "in_progress",
+		RoundIndex:  1,
+		LeaderIndex: 0,
+		PlayerIDs:   []string{"p1", "p2", "p3"},
+		RejectCount: 0,
 	}
 	m := s.ToMap()
 	back := StateFromMap(m)
@@ -51,6 +54,26 @@ func TestClassicAvalonConfig(t *testing.T) {
 	}
 }
 
+func TestDefaultFailsRequiredForPlayerCount(t *testing.T) {
+	if got := DefaultFailsRequiredForPlayerCount(6); got[3] != 1 {
+		t.Errorf("6 players: expected 1 fail on mission 4, got %d", got[3])
+	}
+	if got := DefaultFailsRequiredForPlayerCount(7); got[3] != 2 {
+		t.Errorf("7 players: expected 2 fails on mission 4, got %d", got[3])
+	}
+}
+
+func TestNewGameState_FailsRequiredExceedsTeamSize(t *testing.T) {
+	players := []string{"p1", "p2", "p3", "p4", "p5", "p6", "p7"}
+	rules := ClassicAvalonConfig()
+	// The default table needs 2 fails on mission 4 with 7 players; a host team of 1 cannot fail it.
+	rules.TeamSizes = []int{2, 3, 3, 1, 4}
+	_, err := newGameState(rules, "g1", players, 42)
+	if code, details := ErrorCode(err), ErrorDetails(err); code != CodeInvalidConfig || details["field"] != "fails_required" {
+		t.Errorf("expected INVALID_CONFIG on fails_required, got %v (%s %v)", err, code, details)
+	}
+}
+
 func TestDefaultTeamSizesForPlayerCount(t *testing.T) {
 	for n, wantLen := range map[int]int{5: 5, 6: 5, 7: 5, 10: 5} {
 		got := DefaultTeamSizesForPlayerCount(n)
@@ -93,8 +116,146 @@ func TestApplyMove_BootstrapStartGame(t *testing.T) {
 	if len(result.State.PlayerIDs) != 5 {
 		t.Errorf("expected 5 players, got %d", len(result.State.PlayerIDs))
 	}
-	if len(result.Events) != 1 || result.Events[0].Event != "game_started" {
-		t.Errorf("expected game_started event, got %v", result.Events)
+	if len(result.Events) != 6 || result.Events[0].Event != "game_started" || result.Events[0].To != "" {
+		t.Fatalf("expected public game_started plus one briefing per player, got %v", result.Events)
+	}
+	for i, ev := range result.Events[1:] {
+		if ev.Event != "role_assigned" || ev.To != players[i] {
+			t.Errorf("expected role_assigned private to %s, got %s to %q", players[i], ev.Event, ev.To)
+			continue
+		}
+		if ev.Payload["role"] != result.State.Roles[players[i]] {
+			t.Errorf("%s: expected own role %s, got %v", players[i], result.State.Roles[players[i]], ev.Payload["role"])
+		}
+	}
+	if len(st.roles) != 5 {
+		t.Errorf("expected roles saved for 5 players, got %v", st.roles)
+	}
+}
+
+func TestApplyMove_BootstrapStartGame_FixedSeed(t *testing.T) {
+	players := []string{"p1", "p2", "p3", "p4", "p5"}
+	st := &fakeGameStore{players: players}
+	engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	engine.SetSeedSource(func() (int64, error) { return 7, nil })
+	result := engine.ApplyMove(context.Background(), "game-1", "p1", "action", map[string]interface{}{"action": "start_game"})
+	if result.Error != nil {
+		t.Fatalf("expected success: %v", result.Error)
+	}
+	want := map[string]string{"p1": RoleMinion, "p2": RoleMerlin, "p3": RoleLoyalServant, "p4": RoleLoyalServant, "p5": RoleAssassin}
+	if !reflect.DeepEqual(result.State.Roles, want) {
+		t.Errorf("expected pinned layout %v, got %v", want, result.State.Roles)
+	}
+	if result.State.Seed != 7 || result.State.SeedHash != SeedHash(7) {
+		t.Errorf("expected seed 7 and its hash in state, got %d/%s", result.State.Seed, result.State.SeedHash)
+	}
+	if result.Events[0].Payload["seed_hash"] != SeedHash(7) {
+		t.Errorf("expected game_started to publish the seed hash, got %v", result.Events[0].Payload)
+	}
+}
+
+func TestApplyMove_BootstrapStartGame_RolesFromConfig(t *testing.T) {
+	players := []string{"p1", "p2", "p3", "p4", "p5", "p6", "p7"}
+	st := &fakeGameStore{snapshot: nil, players: players, config: map[string]interface{}{
+		"roles": []interface{}{"merlin", "percival", "morgana", "assassin", "oberon"},
+	}}
+	engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	result := engine.ApplyMove(context.Background(), "game-1", "p1", "action", map[string]interface{}{"action": "start_game"})
+	if result.Error != nil {
+		t.Fatalf("expected success: %v", result.Error)
+	}
+	counts := make(map[string]int)
+	for _, r := range result.State.Roles {
+		counts[r]++
+	}
+	for _, r := range []string{RoleMerlin, RolePercival, RoleMorgana, RoleAssassin, RoleOberon} {
+		if counts[r] != 1 {
+			t.Errorf("expected exactly one %s, got %d", r, counts[r])
+		}
+	}
+	if counts[RoleLoyalServant] != 2 || counts[RoleMinion] != 0 {
+		t.Errorf("expected 2 loyal servants and no minions, got %v", counts)
+	}
+}
+
+func TestApplyMove_StoredConfigWithUnknownField(t *testing.T) {
+	players := []string{"p1", "p2", "p3", "p4", "p5"}
+	st := &fakeGameStore{snapshot: nil, players: players, config: map[string]interface{}{
+		"max_rejections": float64(4),
+		"retired_option": true, // stored by an older server
+	}}
+	engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	result := engine.ApplyMove(context.Background(), "game-1", "p1", "action", map[string]interface{}{"action": "start_game"})
+	if result.Error != nil {
+		t.Fatalf("expected the game to start despite the unknown field: %v", result.Error)
+	}
+}
+
+func TestApplyMove_BootstrapStartGame_IllegalRoleSet(t *testing.T) {
+	players := []string{"p1", "p2", "p3", "p4", "p5"}
+	st := &fakeGameStore{snapshot: nil, players: players, config: map[string]interface{}{
+		"roles": []interface{}{"merlin", "assassin", "morgana", "mordred"},
+	}}
+	engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	result := engine.ApplyMove(context.Background(), "game-1", "p1", "action", map[string]interface{}{"action": "start_game"})
+	if result.Error == nil {
+		t.Error("expected error for 3 evil roles with 5 players")
+	}
+}
+
+func TestApplyMove_BootstrapStartGame_TeamSizes(t *testing.T) {
+	players := []string{"p1", "p2", "p3", "p4", "p5", "p6", "p7", "p8", "p9", "p10"}
+	st := &fakeGameStore{snapshot: nil, players: players}
+	engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	result := engine.ApplyMove(context.Background(), "game-1", "p1", "action", map[string]interface{}{"action": "start_game"})
+	if result.Error != nil {
+		t.Fatalf("expected success: %v", result.Error)
+	}
+	want := DefaultTeamSizesForPlayerCount(10)
+	if len(result.State.TeamSizes) != len(want) || result.State.TeamSizes[0] != want[0] {
+		t.Errorf("expected team sizes %v, got %v", want, result.State.TeamSizes)
+	}
+
+	st = &fakeGameStore{snapshot: nil, players: players, config: map[string]interface{}{
+		"team_sizes": []interface{}{float64(2), float64(2), float64(3), float64(3), float64(4)},
+	}}
+	engine = NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	result = engine.ApplyMove(context.Background(), "game-1", "p1", "action", map[string]interface{}{"action": "start_game"})
+	if result.Error != nil {
+		t.Fatalf("expected success: %v", result.Error)
+	}
+	if result.State.TeamSizes[0] != 2 || result.State.TeamSizes[4] != 4 {
+		t.Errorf("expected host team sizes, got %v", result.State.TeamSizes)
+	}
+
+	st = &fakeGameStore{snapshot: nil, players: players, config: map[string]interface{}{
+		"team_sizes": []interface{}{float64(2), float64(3)},
+	}}
+	engine = NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	result = engine.ApplyMove(context.Background(), "game-1", "p1", "action", map[string]interface{}{"action": "start_game"})
+	if result.Error != nil {
+		t.Fatalf("expected a stored table with too few rounds to be ignored: %v", result.Error)
+	}
+	if !reflect.DeepEqual(result.State.TeamSizes, DefaultTeamSizesForPlayerCount(10)) {
+		t.Errorf("expected default team sizes, got %v", result.State.TeamSizes)
+	}
+}
+
+func TestApplyMove_ProposeTeam_UsesStateTeamSizes(t *testing.T) {
+	players := []string{"p1", "p2", "p3", "p4", "p5", "p6", "p7", "p8", "p9", "p10"}
+	state := &GameState{
+		GameID: "g1", Phase: PhaseTeamSelection, Status: "in_progress",
+		PlayerIDs: players, TeamSizes: DefaultTeamSizesForPlayerCount(10), RoundIndex: 1, LeaderIndex: 0,
+	}
+	st := &fakeGameStore{snapshot: snapshotOf(t, state), players: players}
+	engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	result := engine.ApplyMove(context.Background(), "g1", "p1", "action", map[string]interface{}{"action": "propose_team", "team_ids": []string{"p1", "p2"}})
+	if result.Error == nil {
+		t.Error("expected error for 2-person team in a 10-player game")
+	}
+	result = engine.ApplyMove(context.Background(), "g1", "p1", "action", map[string]interface{}{"action": "propose_team", "team_ids": []string{"p1", "p2", "p3"}})
+	if result.Error != nil {
+		t.Errorf("expected 3-person team to be accepted: %v", result.Error)
 	}
 }
 
@@ -114,6 +275,9 @@ func TestApplyMove_GameFinishedRejectsMove(t *testing.T) {
 	if result.Error != nil && result.Error.Error() != "game already finished" {
 		t.Errorf("expected 'game already finished', got %v", result.Error)
 	}
+	if code := ErrorCode(result.Error); code != CodeGameFinished {
+		t.Errorf("expected code %s, got %s", CodeGameFinished, code)
+	}
 }
 
 func TestApplyMove_VoteNotAllowedInTeamSelection(t *testing.T) {
@@ -129,6 +293,9 @@ func TestApplyMove_VoteNotAllowedInTeamSelection(t *testing.T) {
 	if result.Error == nil {
 		t.Error("expected error for vote in team_selection")
 	}
+	if code, details := ErrorCode(result.Error), ErrorDetails(result.Error); code != CodeWrongPhase || details["phase"] != PhaseTeamSelection {
+		t.Errorf("expected WRONG_PHASE in team_selection, got %s %v", code, details)
+	}
 }
 
 func TestApplyMove_ProposeTeam_NonLeaderRejected(t *testing.T) {
@@ -145,6 +312,9 @@ func TestApplyMove_ProposeTeam_NonLeaderRejected(t *testing.T) {
 	if result.Error == nil {
 		t.Error("expected error when non-leader proposes team")
 	}
+	if code, details := ErrorCode(result.Error), ErrorDetails(result.Error); code != CodeNotLeader || details["leader_id"] != "p1" {
+		t.Errorf("expected NOT_LEADER naming p1, got %s %v", code, details)
+	}
 }
 
 func TestApplyMove_ProposeTeam_WrongSizeRejected(t *testing.T) {
@@ -161,6 +331,9 @@ func TestApplyMove_ProposeTeam_WrongSizeRejected(t *testing.T) {
 	if result.Error == nil {
 		t.Error("expected error for wrong team size")
 	}
+	if code, details := ErrorCode(result.Error), ErrorDetails(result.Error); code != CodeInvalidTeamSize || details["required"] != 2 || details["got"] != 3 {
+		t.Errorf("expected INVALID_TEAM_SIZE requiring 2, got %s %v", code, details)
+	}
 }
 
 func TestApplyMove_ProposeTeam_Success(t *testing.T) {
@@ -245,7 +418,7 @@ func TestApplyMove_TeamVote_AlreadyVoted(t *testing.T) {
 	state := &GameState{
 		GameID: "g1", Phase: PhaseTeamVote, Status: "in_progress",
 		PlayerIDs: []string{"p1", "p2", "p3", "p4", "p5"}, ProposedTeam: []string{"p1", "p2"},
-		TeamVotes: map[string]string{"p1": "approve"},
+		TeamVotes:  map[string]string{"p1": "approve"},
 		RoundIndex: 1, LeaderIndex: 0,
 	}
 	// Snapshot must use map[string]interface{} for nested maps so StateFromMap can parse (e.g. from JSON).
@@ -259,34 +432,405 @@ func TestApplyMove_TeamVote_AlreadyVoted(t *testing.T) {
 	if result.Error == nil {
 		t.Error("expected error for already voted")
 	}
+	if !errors.Is(result.Error, &MoveError{Code: CodeAlreadyVoted}) {
+		t.Errorf("expected ALREADY_VOTED, got %v", result.Error)
+	}
+}
+
+func TestApplyMove_ThirdSuccessStartsAssassination(t *testing.T) {
+	state := &GameState{
+		GameID: "g1", Phase: PhaseMissionVote, Status: "in_progress",
+		PlayerIDs: []string{"p1", "p2", "p3", "p4", "p5"}, ProposedTeam: []string{"p1", "p2", "p3"},
+		MissionVotes:   map[string]string{"p1": "success", "p2": "success"},
+		Roles:          map[string]string{"p1": RoleMerlin, "p2": RoleLoyalServant, "p3": RoleLoyalServant, "p4": RoleAssassin, "p5": RoleMinion},
+		MissionResults: []string{"success", "fail", "success"}, RoundIndex: 4, LeaderIndex: 0,
+	}
+	st := &fakeGameStore{snapshot: snapshotOf(t, state), players: state.PlayerIDs}
+	engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	result := engine.ApplyMove(context.Background(), "g1", "p3", "vote", map[string]interface{}{"success": true})
+	if result.Error != nil {
+		t.Fatalf("expected success: %v", result.Error)
+	}
+	if result.State.Phase != PhaseAssassination || result.State.Status != "in_progress" {
+		t.Errorf("expected in_progress assassination phase, got %s/%s", result.State.Status, result.State.Phase)
+	}
+	if len(result.Events) != 1 || result.Events[0].Event != "assassination_started" {
+		t.Errorf("expected assassination_started event, got %v", result.Events)
+	}
+}
+
+func TestApplyMove_Assassinate(t *testing.T) {
+	state := &GameState{
+		GameID: "g1", Phase: PhaseAssassination, Status: "in_progress",
+		PlayerIDs:      []string{"p1", "p2", "p3", "p4", "p5"},
+		Roles:          map[string]string{"p1": RoleMerlin, "p2": RoleLoyalServant, "p3": RoleLoyalServant, "p4": RoleAssassin, "p5": RoleMinion},
+		MissionResults: []string{"success", "success", "success"}, RoundIndex: 4, LeaderIndex: 0,
+	}
+	for _, tt := range []struct {
+		target     string
+		wantWinner string
+		wantHit    bool
+	}{
+		{"p1", "evil", true},
+		{"p2", "good", false},
+	} {
+		st := &fakeGameStore{snapshot: snapshotOf(t, state), players: state.PlayerIDs}
+		engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+		result := engine.ApplyMove(context.Background(), "g1", "p4", "action", map[string]interface{}{"action": "assassinate", "target_id": tt.target})
+		if result.Error != nil {
+			t.Fatalf("target %s: expected success: %v", tt.target, result.Error)
+		}
+		if result.State.Winner != tt.wantWinner || result.State.Status != "finished" {
+			t.Errorf("target %s: expected finished with winner %s, got %s/%s", tt.target, tt.wantWinner, result.State.Status, result.State.Winner)
+		}
+		if len(result.Events) != 1 || result.Events[0].Payload["merlin_assassinated"] != tt.wantHit {
+			t.Errorf("target %s: expected game_ended with merlin_assassinated=%v, got %v", tt.target, tt.wantHit, result.Events)
+		}
+	}
+
+	st := &fakeGameStore{snapshot: snapshotOf(t, state), players: state.PlayerIDs}
+	engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	result := engine.ApplyMove(context.Background(), "g1", "p5", "action", map[string]interface{}{"action": "assassinate", "target_id": "p1"})
+	if result.Error == nil {
+		t.Error("expected error when a non-assassin assassinates")
+	}
+}
+
+func TestApplyMove_TeamRejected_RejectionsLeft(t *testing.T) {
+	state := &GameState{
+		GameID: "g1", Phase: PhaseTeamVote, Status: "in_progress",
+		PlayerIDs: []string{"p1", "p2", "p3", "p4", "p5"}, ProposedTeam: []string{"p1", "p2"},
+		TeamVotes:   map[string]string{"p1": "reject", "p2": "reject", "p3": "reject", "p4": "approve"},
+		RejectCount: 1, RoundIndex: 1, LeaderIndex: 0,
+	}
+	st := &fakeGameStore{snapshot: snapshotOf(t, state), players: state.PlayerIDs}
+	engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	result := engine.ApplyMove(context.Background(), "g1", "p5", "vote", map[string]interface{}{"approved": false})
+	if result.Error != nil {
+		t.Fatalf("expected success: %v", result.Error)
+	}
+	if len(result.Events) != 2 || result.Events[0].Event != "team_vote_revealed" || result.Events[1].Event != "team_rejected" {
+		t.Fatalf("expected team_vote_revealed then team_rejected, got %v", result.Events)
+	}
+	votes, _ := result.Events[0].Payload["votes"].(map[string]string)
+	if len(votes) != 5 || votes["p4"] != "approve" || votes["p5"] != "reject" {
+		t.Errorf("expected all five votes revealed, got %v", votes)
+	}
+	if left := result.Events[1].Payload["rejections_left"]; left != 3 {
+		t.Errorf("expected 3 rejections left, got %v", left)
+	}
+}
+
+func TestApplyMove_FifthRejectionEndsGame(t *testing.T) {
+	state := &GameState{
+		GameID: "g1", Phase: PhaseTeamVote, Status: "in_progress",
+		PlayerIDs: []string{"p1", "p2", "p3", "p4", "p5"}, ProposedTeam: []string{"p1", "p2"},
+		TeamVotes:   map[string]string{"p1": "reject", "p2": "reject", "p3": "reject", "p4": "approve"},
+		RejectCount: 4, RoundIndex: 2, LeaderIndex: 0,
+	}
+	st := &fakeGameStore{snapshot: snapshotOf(t, state), players: state.PlayerIDs}
+	engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	result := engine.ApplyMove(context.Background(), "g1", "p5", "vote", map[string]interface{}{"approved": false})
+	if result.Error != nil {
+		t.Fatalf("expected success: %v", result.Error)
+	}
+	if result.State.Status != "finished" || result.State.Winner != "evil" {
+		t.Errorf("expected evil win, got %s/%s", result.State.Status, result.State.Winner)
+	}
+	if len(result.Events) != 2 || result.Events[1].Event != "game_ended" {
+		t.Errorf("expected team_vote_revealed then game_ended, got %v", result.Events)
+	}
+}
+
+func TestApplyMove_FourthMissionNeedsTwoFails(t *testing.T) {
+	players := []string{"p1", "p2", "p3", "p4", "p5", "p6", "p7"}
+	for _, tt := range []struct {
+		votes      map[string]string
+		wantResult string
+	}{
+		{map[string]string{"p1": "fail", "p2": "success", "p3": "success"}, "success"},
+		{map[string]string{"p1": "fail", "p2": "fail", "p3": "success"}, "fail"},
+	} {
+		state := &GameState{
+			GameID: "g1", Phase: PhaseMissionVote, Status: "in_progress",
+			PlayerIDs: players, ProposedTeam: []string{"p1", "p2", "p3", "p4"}, MissionVotes: tt.votes,
+			MissionResults: []string{"success", "fail", "fail"}, RoundIndex: 4, LeaderIndex: 0,
+		}
+		st := &fakeGameStore{snapshot: snapshotOf(t, state), players: players}
+		engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+		result := engine.ApplyMove(context.Background(), "g1", "p4", "vote", map[string]interface{}{"success": true})
+		if result.Error != nil {
+			t.Fatalf("expected success: %v", result.Error)
+		}
+		if len(result.Events) != 1 {
+			t.Fatalf("expected one event, got %v", result.Events)
+		}
+		payload := result.Events[0].Payload
+		if payload["fails_required"] != 2 {
+			t.Errorf("expected fails_required 2, got %v", payload["fails_required"])
+		}
+		got := result.State.MissionResults[len(result.State.MissionResults)-1]
+		if got != tt.wantResult {
+			t.Errorf("votes %v: expected %s, got %s", tt.votes, tt.wantResult, got)
+		}
+	}
+}
+
+func TestApplyMove_UsesPerGameRules(t *testing.T) {
+	state := &GameState{
+		GameID: "g1", Phase: PhaseTeamVote, Status: "in_progress",
+		PlayerIDs: []string{"p1", "p2", "p3", "p4", "p5"}, ProposedTeam: []string{"p1", "p2"},
+		TeamVotes:   map[string]string{"p1": "reject", "p2": "reject", "p3": "reject", "p4": "approve"},
+		RejectCount: 1, RoundIndex: 1, LeaderIndex: 0,
+	}
+	st := &fakeGameStore{snapshot: snapshotOf(t, state), players: state.PlayerIDs,
+		config: map[string]interface{}{"max_rejections": float64(2)}}
+	engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	result := engine.ApplyMove(context.Background(), "g1", "p5", "vote", map[string]interface{}{"approved": false})
+	if result.Error != nil {
+		t.Fatalf("expected success: %v", result.Error)
+	}
+	if result.State.Status != "finished" || result.State.Winner != "evil" {
+		t.Errorf("expected game's max_rejections=2 to end the game, got %s/%s", result.State.Status, result.State.Winner)
+	}
+}
+
+func TestApplyMove_LadyOfTheLake(t *testing.T) {
+	players := []string{"p1", "p2", "p3", "p4", "p5"}
+	lady := map[string]interface{}{"modules": map[string]interface{}{"lady_of_the_lake": true}}
+
+	st := &fakeGameStore{players: players, config: lady}
+	engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	result := engine.ApplyMove(context.Background(), "g1", "p1", "action", map[string]interface{}{"action": "start_game"})
+	if result.Error != nil {
+		t.Fatalf("start_game: %v", result.Error)
+	}
+	if result.State.LadyHolder != "p5" {
+		t.Errorf("expected the player before the first leader to hold the token, got %q", result.State.LadyHolder)
+	}
+
+	// Second mission resolves -> lady_of_the_lake phase.
+	state := &GameState{
+		GameID: "g1", Phase: PhaseMissionVote, Status: "in_progress",
+		PlayerIDs: players, ProposedTeam: []string{"p1", "p2", "p3"},
+		MissionVotes:   map[string]string{"p1": "success", "p2": "success"},
+		Roles:          map[string]string{"p1": RoleMerlin, "p2": RoleLoyalServant, "p3": RoleLoyalServant, "p4": RoleAssassin, "p5": RoleMinion},
+		MissionResults: []string{"success"}, RoundIndex: 2, LeaderIndex: 1,
+		LadyHolder: "p5", LadyHolders: []string{"p5"},
+	}
+	st = &fakeGameStore{snapshot: snapshotOf(t, state), players: players, config: lady}
+	engine = NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	result = engine.ApplyMove(context.Background(), "g1", "p3", "vote", map[string]interface{}{"success": true})
+	if result.Error != nil {
+		t.Fatalf("mission vote: %v", result.Error)
+	}
+	if result.State.Phase != PhaseLadyOfTheLake {
+		t.Fatalf("expected lady_of_the_lake phase after mission 2, got %s", result.State.Phase)
+	}
+
+	st.snapshot = snapshotOf(t, result.State)
+	if r := engine.ApplyMove(context.Background(), "g1", "p1", "action", map[string]interface{}{"action": "inspect", "target_id": "p4"}); r.Error == nil {
+		t.Error("expected error when a non-holder inspects")
+	}
+	if r := engine.ApplyMove(context.Background(), "g1", "p5", "action", map[string]interface{}{"action": "inspect", "target_id": "p5"}); r.Error == nil {
+		t.Error("expected error when inspecting a previous holder")
+	}
+	result = engine.ApplyMove(context.Background(), "g1", "p5", "action", map[string]interface{}{"action": "inspect", "target_id": "p4"})
+	if result.Error != nil {
+		t.Fatalf("inspect: %v", result.Error)
+	}
+	if result.State.Phase != PhaseTeamSelection || result.State.LadyHolder != "p4" {
+		t.Errorf("expected team_selection with p4 holding the token, got %s/%s", result.State.Phase, result.State.LadyHolder)
+	}
+	if len(result.Events) != 2 {
+		t.Fatalf("expected public and private events, got %v", result.Events)
+	}
+	public, private := result.Events[0], result.Events[1]
+	if public.To != "" || public.Payload["alignment"] != nil {
+		t.Errorf("public event must not reveal alignment: %+v", public)
+	}
+	if private.Event != "lady_result" || private.To != "p5" || private.Payload["alignment"] != AlignmentEvil {
+		t.Errorf("expected private evil result for p5, got %+v", private)
+	}
+}
+
+func TestApplyMove_LadyOfTheLakeDisabled(t *testing.T) {
+	players := []string{"p1", "p2", "p3", "p4", "p5"}
+	state := &GameState{
+		GameID: "g1", Phase: PhaseMissionVote, Status: "in_progress",
+		PlayerIDs: players, ProposedTeam: []string{"p1", "p2", "p3"},
+		MissionVotes:   map[string]string{"p1": "success", "p2": "success"},
+		MissionResults: []string{"success"}, RoundIndex: 2, LeaderIndex: 1,
+	}
+	st := &fakeGameStore{snapshot: snapshotOf(t, state), players: players}
+	engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	result := engine.ApplyMove(context.Background(), "g1", "p3", "vote", map[string]interface{}{"success": true})
+	if result.Error != nil {
+		t.Fatalf("mission vote: %v", result.Error)
+	}
+	if result.State.Phase != PhaseTeamSelection {
+		t.Errorf("expected team_selection without the module, got %s", result.State.Phase)
+	}
+}
+
+func TestApplyMove_RetriesStaleState(t *testing.T) {
+	state := &GameState{
+		GameID: "g1", Phase: PhaseTeamVote, Status: "in_progress", Version: 4,
+		PlayerIDs: []string{"p1", "p2", "p3", "p4", "p5"}, ProposedTeam: []string{"p1", "p2"},
+		TeamVotes: map[string]string{}, RoundIndex: 1, LeaderIndex: 0,
+	}
+	st := &fakeGameStore{snapshot: snapshotOf(t, state), players: state.PlayerIDs, conflicts: 1}
+	engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	result := engine.ApplyMove(context.Background(), "g1", "p1", "vote", map[string]interface{}{"approved": true})
+	if result.Error != nil {
+		t.Fatalf("expected the move to succeed after one conflict: %v", result.Error)
+	}
+	if result.State.Version != 5 || len(st.events) != 1 {
+		t.Errorf("expected version 5 and one event, got version %d and %d events", result.State.Version, len(st.events))
+	}
+
+	st = &fakeGameStore{snapshot: snapshotOf(t, state), players: state.PlayerIDs, conflicts: maxMoveAttempts}
+	engine = NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	result = engine.ApplyMove(context.Background(), "g1", "p1", "vote", map[string]interface{}{"approved": true})
+	if !errors.Is(result.Error, store.ErrStaleState) || ErrorCode(result.Error) != CodeStaleState {
+		t.Errorf("expected stale state error, got %v", result.Error)
+	}
+	if len(st.events) != 0 {
+		t.Errorf("expected no event written, got %d", len(st.events))
+	}
+}
+
+func TestApplyMove_SecondVoteSeesFirst(t *testing.T) {
+	state := &GameState{
+		GameID: "g1", Phase: PhaseTeamVote, Status: "in_progress", Version: 1,
+		PlayerIDs: []string{"p1", "p2", "p3", "p4", "p5"}, ProposedTeam: []string{"p1", "p2"},
+		TeamVotes: map[string]string{}, RoundIndex: 1, LeaderIndex: 0,
+	}
+	st := &fakeGameStore{snapshot: snapshotOf(t, state), players: state.PlayerIDs}
+	engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	for _, p := range []string{"p1", "p2"} {
+		if r := engine.ApplyMove(context.Background(), "g1", p, "vote", map[string]interface{}{"approved": true}); r.Error != nil {
+			t.Fatalf("%s vote: %v", p, r.Error)
+		}
+	}
+	if got := StateFromMap(st.snapshot); len(got.TeamVotes) != 2 || got.Version != 3 {
+		t.Errorf("expected both votes at version 3, got %v at %d", got.TeamVotes, got.Version)
+	}
+}
+
+// snapshotOf round-trips state through JSON so nested maps match what the DB returns.
+func snapshotOf(t *testing.T, state *GameState) map[string]interface{} {
+	t.Helper()
+	b, err := json.Marshal(state.ToMap())
+	if err != nil {
+		t.Fatalf("marshal state: %v", err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatalf("unmarshal state: %v", err)
+	}
+	return m
 }
 
 // Minimal fakes for engine tests without DB.
 type fakeGameStore struct {
 	snapshot map[string]interface{}
 	players  []string
+	config   map[string]interface{}
+	host     string
+	members  []string // room members without a seat
+	roles    map[string]string
+	events   []store.CreateGameEventRequest
+	seats    []store.SeatChange
+	history  map[int32]map[string]interface{} // snapshots committed by CommitMove, by version
+	// conflicts: number of CommitMove calls that fail with ErrStaleState, as if another move committed first.
+	conflicts int
+	// spectators: user ID -> the room_player_id SeatSpectator gives them.
+	spectators map[string]string
 }
 
 func (f *fakeGameStore) GetLatestSnapshot(ctx context.Context, gameID string) (map[string]interface{}, error) {
 	return f.snapshot, nil
 }
-func (f *fakeGameStore) CreateOrUpdateSnapshot(ctx context.Context, gameID string, stateJSON map[string]interface{}) (int32, error) {
-	f.snapshot = stateJSON
-	return 1, nil
-}
-func (f *fakeGameStore) UpdateGameStatus(ctx context.Context, gameID string, status string, endedAt *time.Time) error {
-	return nil
+func (f *fakeGameStore) GetSnapshotAtVersion(ctx context.Context, gameID string, version int32) (map[string]interface{}, error) {
+	return f.history[version], nil
 }
 func (f *fakeGameStore) GetGamePlayerIDsInOrder(ctx context.Context, gameID string) ([]string, error) {
 	return f.players, nil
 }
+func (f *fakeGameStore) GetGameConfig(ctx context.Context, gameID string) (map[string]interface{}, error) {
+	return f.config, nil
+}
+func (f *fakeGameStore) GetGameHostID(ctx context.Context, gameID string) (string, error) {
+	return f.host, nil
+}
+func (f *fakeGameStore) IsGameRoomMember(ctx context.Context, gameID string, roomPlayerID string) (bool, error) {
+	for _, id := range append(f.players, f.members...) {
+		if id == roomPlayerID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+func (f *fakeGameStore) SeatSpectator(ctx context.Context, gameID string, userID string) (string, error) {
+	id := f.spectators[userID]
+	if id != "" {
+		f.members = append(f.members, id)
+	}
+	return id, nil
+}
+func (f *fakeGameStore) CommitMove(ctx context.Context, gameID string, commit store.MoveCommit) (int32, error) {
+	current, _ := floatToInt(f.snapshot["version"])
+	if f.conflicts > 0 || int32(current) != commit.ExpectedVersion {
+		f.conflicts--
+		return 0, store.ErrStaleState
+	}
+	b, err := json.Marshal(commit.State)
+	if err != nil {
+		return 0, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return 0, err
+	}
+	m["version"] = float64(current + 1)
+	f.snapshot = m
+	if f.history == nil {
+		f.history = make(map[int32]map[string]interface{})
+	}
+	f.history[int32(current+1)] = m
+	if commit.Event != nil {
+		f.events = append(f.events, *commit.Event)
+	}
+	if commit.Roles != nil {
+		f.roles = commit.Roles
+	}
+	f.seats = append(f.seats, commit.Seats...)
+	return int32(current + 1), nil
+}
+
+type fakeEventStore struct {
+	events []store.GameEvent
+}
 
-type fakeEventStore struct{}
+func (f *fakeEventStore) GetGameEvents(ctx context.Context, gameID string) ([]store.GameEvent, error) {
+	return f.events, nil
+}
 
-func (f *fakeEventStore) CreateGameEvent(ctx context.Context, req store.CreateGameEventRequest) (*store.GameEvent, error) {
-	pl := req.Payload
-	if pl == nil {
-		pl = make(map[string]interface{})
+// loggedEvents returns the events committed to f as the event store would read them back (JSON payloads).
+func (f *fakeGameStore) loggedEvents(t *testing.T) []store.GameEvent {
+	t.Helper()
+	out := make([]store.GameEvent, 0, len(f.events))
+	for i, req := range f.events {
+		b, err := json.Marshal(req.Payload)
+		if err != nil {
+			t.Fatalf("marshal payload: %v", err)
+		}
+		var payload map[string]interface{}
+		if err := json.Unmarshal(b, &payload); err != nil {
+			t.Fatalf("unmarshal payload: %v", err)
+		}
+		out = append(out, store.GameEvent{ID: fmt.Sprintf("ev-%d", i), GameID: req.GameID, RoomPlayerID: req.RoomPlayerID, Type: req.Type, Payload: payload})
 	}
-	return &store.GameEvent{ID: "fake-id", GameID: req.GameID, Type: req.Type, Payload: pl}, nil
+	return out
 }
@@ -0,0 +1,100 @@
+package games
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vntrieu/avalon/internal/store"
+)
+
+// Error codes reported with rejected moves. They are stable, so clients can match on them instead of the message.
+const (
+	CodeGameNotStarted     = "GAME_NOT_STARTED"
+	CodeGameFinished       = "GAME_FINISHED"
+	CodeGamePaused         = "GAME_PAUSED"
+	CodeGameNotPaused      = "GAME_NOT_PAUSED"
+	CodeWrongPhase         = "WRONG_PHASE"
+	CodeUnknownMove        = "UNKNOWN_MOVE"
+	CodeInvalidPayload     = "INVALID_PAYLOAD"
+	CodeNotInGame          = "NOT_IN_GAME"
+	CodeNotHost            = "NOT_HOST"
+	CodeNotLeader          = "NOT_LEADER"
+	CodeNotOnTeam          = "NOT_ON_TEAM"
+	CodeNotAssassin        = "NOT_ASSASSIN"
+	CodeNotLadyHolder      = "NOT_LADY_HOLDER"
+	CodeAlreadyVoted       = "ALREADY_VOTED"
+	CodeInvalidTeamSize    = "INVALID_TEAM_SIZE"
+	CodeInvalidTarget      = "INVALID_TARGET"
+	CodeInvalidPlayerCount = "INVALID_PLAYER_COUNT"
+	CodeInvalidConfig      = "INVALID_CONFIG"
+	CodeReconnectPending   = "RECONNECT_PENDING"
+	CodePlayerConnected    = "PLAYER_CONNECTED"
+	CodeSeatFlagged        = "SEAT_ALREADY_FLAGGED"
+	CodeInvalidReplacement = "INVALID_REPLACEMENT"
+	CodeStaleState         = "STALE_STATE"
+	CodeInternal           = "INTERNAL"
+)
+
+// MoveError is a move the engine rejected: a stable Code, a human-readable Message and optional Details
+// (e.g. the phase, or the required team size) for clients to build their own message from.
+type MoveError struct {
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+func (e *MoveError) Error() string {
+	return e.Message
+}
+
+// Is reports whether target is a *MoveError with the same code, so errors.Is(err, &MoveError{Code: CodeAlreadyVoted}) works.
+func (e *MoveError) Is(target error) bool {
+	t, ok := target.(*MoveError)
+	return ok && t.Code == e.Code
+}
+
+// moveError builds a *MoveError with the message from format and args. details may be nil.
+func moveError(code string, details map[string]interface{}, format string, args ...interface{}) *MoveError {
+	return &MoveError{Code: code, Message: fmt.Sprintf(format, args...), Details: details}
+}
+
+// wrongPhase is the MoveError for a move the current phase does not accept.
+func wrongPhase(phase string, format string, args ...interface{}) *MoveError {
+	return moveError(CodeWrongPhase, map[string]interface{}{"phase": phase}, format, args...)
+}
+
+// missingField is the MoveError for a payload without a required field.
+func missingField(field string, format string, args ...interface{}) *MoveError {
+	return moveError(CodeInvalidPayload, map[string]interface{}{"field": field}, format, args...)
+}
+
+// ErrorCode returns the code for err: the MoveError's code, INVALID_CONFIG for a *ConfigError,
+// STALE_STATE for store.ErrStaleState, and INTERNAL for anything else (e.g. a database failure).
+func ErrorCode(err error) string {
+	var moveErr *MoveError
+	var cfgErr *ConfigError
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &moveErr):
+		return moveErr.Code
+	case errors.As(err, &cfgErr):
+		return CodeInvalidConfig
+	case errors.Is(err, store.ErrStaleState):
+		return CodeStaleState
+	}
+	return CodeInternal
+}
+
+// ErrorDetails returns the details of a *MoveError in err's chain, or the invalid fields of a *ConfigError; otherwise nil.
+func ErrorDetails(err error) map[string]interface{} {
+	var moveErr *MoveError
+	if errors.As(err, &moveErr) {
+		return moveErr.Details
+	}
+	var cfgErr *ConfigError
+	if errors.As(err, &cfgErr) {
+		return map[string]interface{}{"fields": cfgErr.Fields}
+	}
+	return nil
+}
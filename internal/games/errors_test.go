@@ -0,0 +1,35 @@
+package games
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorCode(t *testing.T) {
+	notLeader := moveError(CodeNotLeader, map[string]interface{}{"leader_id": "p1"}, "only the leader can propose a team")
+	tests := []struct {
+		name string
+		err  error
+		code string
+	}{
+		{"nil", nil, ""},
+		{"move error", notLeader, CodeNotLeader},
+		{"wrapped move error", fmt.Errorf("start game: %w", notLeader), CodeNotLeader},
+		{"config error", &ConfigError{Fields: []FieldError{{Field: "roles", Message: "bad"}}}, CodeInvalidConfig},
+		{"other error", errors.New("get state: connection refused"), CodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorCode(tt.err); got != tt.code {
+				t.Errorf("ErrorCode = %q, want %q", got, tt.code)
+			}
+		})
+	}
+	if d := ErrorDetails(fmt.Errorf("wrapped: %w", notLeader)); d["leader_id"] != "p1" {
+		t.Errorf("expected details through wrapping, got %v", d)
+	}
+	if !errors.Is(notLeader, &MoveError{Code: CodeNotLeader}) || errors.Is(notLeader, &MoveError{Code: CodeAlreadyVoted}) {
+		t.Error("expected errors.Is to match on code")
+	}
+}
@@ -0,0 +1,66 @@
+package games
+
+import "strings"
+
+// MaxAbortReasonLength is the longest abort reason kept; longer reasons are truncated.
+const MaxAbortReasonLength = 200
+
+// DefaultAbortReason is the end_reason of a game aborted without a reason.
+const DefaultAbortReason = "aborted by host"
+
+// IsHostAction reports whether action may only be sent by the room host.
+func IsHostAction(action string) bool {
+	switch action {
+	case ActionPauseGame, ActionResumeGame, ActionAbortGame, ActionReplacePlayer, ActionFlagSeat:
+		return true
+	}
+	return false
+}
+
+// applyHostAction pauses, resumes or aborts a started game, or applies a seat action (see applySeatAction).
+// The caller has checked that roomPlayerID is the host.
+// Pausing keeps the phase and stops its timer (see scheduleDeadline); other moves are rejected until resume_game.
+// Aborting finishes the game with no winner and payload "reason" as end_reason.
+func (e *Engine) applyHostAction(state *GameState, roomPlayerID string, action string, payload map[string]interface{}) (*GameState, []BroadcastEvent, error) {
+	switch action {
+	case ActionReplacePlayer, ActionFlagSeat:
+		return e.applySeatAction(state, roomPlayerID, action, payload)
+	case ActionPauseGame:
+		if state.Status == "paused" {
+			return nil, nil, moveError(CodeGamePaused, nil, "game is already paused")
+		}
+		next := state.Clone()
+		next.Status = "paused"
+		ev := BroadcastEvent{Event: "game_paused", Payload: map[string]interface{}{"paused_by": roomPlayerID, "phase": next.Phase}}
+		return next, []BroadcastEvent{ev}, nil
+	case ActionResumeGame:
+		if state.Status != "paused" {
+			return nil, nil, moveError(CodeGameNotPaused, nil, "game is not paused")
+		}
+		next := state.Clone()
+		next.Status = "in_progress"
+		ev := BroadcastEvent{Event: "game_resumed", Payload: map[string]interface{}{"resumed_by": roomPlayerID, "phase": next.Phase}}
+		return next, []BroadcastEvent{ev}, nil
+	case ActionAbortGame:
+		reason, _ := payload["reason"].(string)
+		reason = strings.TrimSpace(reason)
+		if reason == "" {
+			reason = DefaultAbortReason
+		}
+		if r := []rune(reason); len(r) > MaxAbortReasonLength {
+			reason = string(r[:MaxAbortReasonLength])
+		}
+		next := state.Clone()
+		next.Status = "finished"
+		next.Phase = PhaseFinished
+		next.Winner = ""
+		next.EndReason = reason
+		next.ProposedTeam = nil
+		next.TeamVotes = nil
+		next.MissionVotes = nil
+		ev := BroadcastEvent{Event: "game_ended", Payload: map[string]interface{}{
+			"aborted": true, "reason": reason, "aborted_by": roomPlayerID}}
+		return next, []BroadcastEvent{ev}, nil
+	}
+	return nil, nil, moveError(CodeUnknownMove, map[string]interface{}{"action": action}, "action %q is not a host action", action)
+}
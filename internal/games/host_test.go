@@ -0,0 +1,88 @@
+package games
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestApplyMove_PauseResume(t *testing.T) {
+	players := []string{"p1", "p2", "p3", "p4", "p5"}
+	st := &fakeGameStore{players: players, config: timerConfig, host: "p3"}
+	engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	engine.SetClock(func() time.Time { return now })
+	ctx := context.Background()
+
+	if r := engine.ApplyMove(ctx, "g1", "p1", "action", map[string]interface{}{"action": "start_game"}); r.Error != nil {
+		t.Fatalf("start_game: %v", r.Error)
+	}
+	now = now.Add(20 * time.Second)
+	if r := engine.ApplyMove(ctx, "g1", "p1", "action", map[string]interface{}{"action": "pause_game"}); r.Error == nil {
+		t.Error("expected error when a non-host pauses")
+	}
+	result := engine.ApplyMove(ctx, "g1", "p3", "action", map[string]interface{}{"action": "pause_game"})
+	if result.Error != nil {
+		t.Fatalf("pause_game: %v", result.Error)
+	}
+	if result.State.Status != "paused" || result.State.Deadline != "" || result.State.PausedTimeLeft != 40 {
+		t.Errorf("expected paused with 40s left and no deadline, got %s/%q/%d", result.State.Status, result.State.Deadline, result.State.PausedTimeLeft)
+	}
+	if len(result.Events) != 1 || result.Events[0].Event != "game_paused" {
+		t.Errorf("expected game_paused, got %v", result.Events)
+	}
+
+	if r := engine.ApplyMove(ctx, "g1", "p1", "action", map[string]interface{}{"action": "propose_team", "team_ids": []interface{}{"p1", "p2"}}); r.Error == nil {
+		t.Error("expected moves to be rejected while paused")
+	}
+	now = now.Add(10 * time.Minute)
+	if r := engine.ApplyTimeout(ctx, "g1"); r.Error != nil || r.State != nil {
+		t.Errorf("expected no timeout while paused, got %+v", r)
+	}
+
+	result = engine.ApplyMove(ctx, "g1", "p3", "action", map[string]interface{}{"action": "resume_game"})
+	if result.Error != nil {
+		t.Fatalf("resume_game: %v", result.Error)
+	}
+	if result.State.Status != "in_progress" || result.State.Deadline != "2025-03-01T12:11:00Z" {
+		t.Errorf("expected the timer to restart with 40s left, got %s/%q", result.State.Status, result.State.Deadline)
+	}
+	if r := engine.ApplyMove(ctx, "g1", "p3", "action", map[string]interface{}{"action": "resume_game"}); r.Error == nil {
+		t.Error("expected error when resuming a running game")
+	}
+	if len(st.events) != 3 || st.events[1].Payload["action"] != ActionPauseGame || st.events[2].Payload["action"] != ActionResumeGame {
+		t.Errorf("expected start, pause and resume in the event log, got %+v", st.events)
+	}
+}
+
+func TestApplyMove_AbortGame(t *testing.T) {
+	players := []string{"p1", "p2", "p3", "p4", "p5"}
+	st := &fakeGameStore{snapshot: map[string]interface{}{"phase": "lobby", "version": float64(1)}, players: players, host: "p1"}
+	engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	ctx := context.Background()
+
+	if r := engine.ApplyMove(ctx, "g1", "p1", "action", map[string]interface{}{"action": "start_game"}); r.Error != nil {
+		t.Fatalf("start_game: %v", r.Error)
+	}
+	if r := engine.ApplyMove(ctx, "g1", "p1", "action", map[string]interface{}{"action": "pause_game"}); r.Error != nil {
+		t.Fatalf("pause_game: %v", r.Error)
+	}
+	result := engine.ApplyMove(ctx, "g1", "p1", "action", map[string]interface{}{"action": "abort_game", "reason": "  power cut "})
+	if result.Error != nil {
+		t.Fatalf("abort_game: %v", result.Error)
+	}
+	if result.State.Status != "finished" || result.State.Winner != "" || result.State.EndReason != "power cut" {
+		t.Errorf("expected finished with no winner and the reason, got %s/%q/%q", result.State.Status, result.State.Winner, result.State.EndReason)
+	}
+	if ev := result.Events[0]; ev.Event != "game_ended" || ev.Payload["aborted"] != true || ev.Payload["reason"] != "power cut" {
+		t.Errorf("expected game_ended with the abort reason, got %+v", ev)
+	}
+	if r := engine.ApplyMove(ctx, "g1", "p1", "action", map[string]interface{}{"action": "resume_game"}); r.Error == nil {
+		t.Error("expected error after the game was aborted")
+	}
+
+	replayEngine := NewEngine(st, &fakeEventStore{events: st.loggedEvents(t)}, ClassicAvalonConfig())
+	if _, diff, err := replayEngine.VerifySnapshot(ctx, "g1"); err != nil || len(diff) != 0 {
+		t.Errorf("expected replay to match the aborted game, got diff %v err %v", diff, err)
+	}
+}
@@ -0,0 +1,139 @@
+package games
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// lobbySnapshotVersion is the version of the lobby snapshot written when a game is created;
+// start_game commits the next version and each later move one more.
+const lobbySnapshotVersion = 1
+
+// Replay rebuilds a game's state from its event log without reading any snapshot: the start_game event's
+// seed and seating give the initial deal, then every later vote/action/timeout/connection move is folded over it with the game's rules.
+// Only events written by the engine (payload move_type equal to the event type) are moves.
+// Returns a lobby state if the game has not started.
+func (e *Engine) Replay(ctx context.Context, gameID string) (*GameState, error) {
+	rules, err := e.rulesFor(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	events, err := e.events.GetGameEvents(ctx, gameID)
+	if err != nil {
+		return nil, fmt.Errorf("get events: %w", err)
+	}
+	state := &GameState{Phase: PhaseLobby, Version: lobbySnapshotVersion}
+	started := false
+	for _, ev := range events {
+		moveType, _ := ev.Payload["move_type"].(string)
+		if moveType == "" || moveType != ev.Type {
+			continue
+		}
+		var roomPlayerID string
+		if ev.RoomPlayerID != nil {
+			roomPlayerID = *ev.RoomPlayerID
+		}
+		if !started {
+			if action, _ := ev.Payload["action"].(string); action != ActionStartGame {
+				return nil, fmt.Errorf("event %s: %s before start_game", ev.ID, ev.Type)
+			}
+			seed, playerIDs, err := startGameParams(ev.Payload)
+			if err != nil {
+				return nil, fmt.Errorf("event %s: %w", ev.ID, err)
+			}
+			next, err := newGameState(rules, gameID, playerIDs, seed)
+			if err != nil {
+				return nil, fmt.Errorf("event %s: %w", ev.ID, err)
+			}
+			next.Version = state.Version + 1
+			state = next
+			started = true
+			continue
+		}
+		var next *GameState
+		switch ev.Type {
+		case "vote":
+			next, _, err = e.applyVote(ctx, rules, state, roomPlayerID, ev.Payload)
+		case "action":
+			next, _, err = e.applyAction(ctx, rules, state, roomPlayerID, ev.Payload)
+		case MoveTypeTimeout:
+			next, _, err = e.applyTimeout(ctx, rules, state)
+		case MoveTypeConnection:
+			next, _, err = applyConnection(state, roomPlayerID, ev.Payload)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("event %s: %w", ev.ID, err)
+		}
+		if next == nil {
+			return nil, fmt.Errorf("event %s: unknown move type %q", ev.ID, ev.Type)
+		}
+		next.Version = state.Version + 1
+		state = next
+	}
+	return state, nil
+}
+
+// VerifySnapshot replays a game and compares the result with its latest snapshot.
+// Returns the replayed state and the fields that differ (see DiffStates); no fields means the snapshot is consistent.
+func (e *Engine) VerifySnapshot(ctx context.Context, gameID string) (*GameState, []string, error) {
+	replayed, err := e.Replay(ctx, gameID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("replay: %w", err)
+	}
+	snapshot, err := e.GetState(ctx, gameID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get state: %w", err)
+	}
+	return replayed, DiffStates(snapshot, replayed), nil
+}
+
+// DiffStates returns the sorted snapshot keys (e.g. "roles", "mission_results") whose values differ between a and b.
+// Version and the timer fields (wall-clock times, not derived from the log) are ignored. A nil state counts as the lobby.
+func DiffStates(a, b *GameState) []string {
+	am, bm := lobbyIfNil(a).ToMap(), lobbyIfNil(b).ToMap()
+	for _, k := range []string{"version", "deadline", "paused_time_left"} {
+		delete(am, k)
+		delete(bm, k)
+	}
+	keys := make(map[string]bool, len(am)+len(bm))
+	for k := range am {
+		keys[k] = true
+	}
+	for k := range bm {
+		keys[k] = true
+	}
+	var diff []string
+	for k := range keys {
+		av, _ := json.Marshal(am[k])
+		bv, _ := json.Marshal(bm[k])
+		if string(av) != string(bv) {
+			diff = append(diff, k)
+		}
+	}
+	sort.Strings(diff)
+	return diff
+}
+
+func lobbyIfNil(s *GameState) *GameState {
+	if s == nil {
+		return &GameState{Phase: PhaseLobby}
+	}
+	return s
+}
+
+// startGameParams reads the seed and seating logged with a start_game event.
+func startGameParams(payload map[string]interface{}) (int64, []string, error) {
+	raw, _ := payload["seed"].(string)
+	seed, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return 0, nil, fmt.Errorf("start_game event has no valid seed")
+	}
+	playerIDs, ok := stringSlice(payload["player_ids"])
+	if !ok || len(playerIDs) == 0 {
+		return 0, nil, fmt.Errorf("start_game event has no player_ids")
+	}
+	return seed, playerIDs, nil
+}
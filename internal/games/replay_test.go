@@ -0,0 +1,92 @@
+package games
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestReplay_MatchesSnapshot(t *testing.T) {
+	players := []string{"p1", "p2", "p3", "p4", "p5"}
+	st := &fakeGameStore{snapshot: map[string]interface{}{"phase": "lobby", "version": float64(1)}, players: players}
+	engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	ctx := context.Background()
+
+	moves := []struct {
+		player   string
+		moveType string
+		payload  map[string]interface{}
+	}{
+		{"p1", "action", map[string]interface{}{"action": "start_game"}},
+		{"p1", "action", map[string]interface{}{"action": "propose_team", "team_ids": []interface{}{"p1", "p2"}}},
+		{"p1", "vote", map[string]interface{}{"approved": true}},
+		{"p2", "vote", map[string]interface{}{"approved": true}},
+		{"p3", "vote", map[string]interface{}{"approved": false}},
+		{"p4", "vote", map[string]interface{}{"approved": true}},
+		{"p5", "vote", map[string]interface{}{"approved": false}},
+		{"p1", "vote", map[string]interface{}{"success": true}},
+		{"p2", "vote", map[string]interface{}{"success": false}},
+	}
+	var last *GameState
+	for i, m := range moves {
+		result := engine.ApplyMove(ctx, "g1", m.player, m.moveType, m.payload)
+		if result.Error != nil {
+			t.Fatalf("move %d: %v", i, result.Error)
+		}
+		last = result.State
+	}
+
+	replayEngine := NewEngine(st, &fakeEventStore{events: st.loggedEvents(t)}, ClassicAvalonConfig())
+	replayed, diff, err := replayEngine.VerifySnapshot(ctx, "g1")
+	if err != nil {
+		t.Fatalf("VerifySnapshot: %v", err)
+	}
+	if len(diff) != 0 {
+		t.Errorf("expected replay to match the snapshot, differs in %v", diff)
+	}
+	if replayed.Version != last.Version {
+		t.Errorf("expected replayed version %d, got %d", last.Version, replayed.Version)
+	}
+	if !reflect.DeepEqual(replayed.Roles, last.Roles) {
+		t.Errorf("expected the same deal, got %v want %v", replayed.Roles, last.Roles)
+	}
+
+	// A corrupted snapshot is detected.
+	st.snapshot["winner"] = "evil"
+	st.snapshot["round_index"] = float64(5)
+	_, diff, err = replayEngine.VerifySnapshot(ctx, "g1")
+	if err != nil {
+		t.Fatalf("VerifySnapshot: %v", err)
+	}
+	if !reflect.DeepEqual(diff, []string{"round_index", "winner"}) {
+		t.Errorf("expected round_index and winner to differ, got %v", diff)
+	}
+}
+
+func TestReplay_NotStarted(t *testing.T) {
+	st := &fakeGameStore{snapshot: map[string]interface{}{"phase": "lobby", "version": float64(1)}}
+	engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	state, err := engine.Replay(context.Background(), "g1")
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if state.Phase != PhaseLobby {
+		t.Errorf("expected lobby, got %s", state.Phase)
+	}
+}
+
+func TestNewGameState_SameSeedSameDeal(t *testing.T) {
+	players := []string{"p1", "p2", "p3", "p4", "p5", "p6", "p7"}
+	rules := ClassicAvalonConfig()
+	a, err := newGameState(rules, "g1", players, 42)
+	if err != nil {
+		t.Fatalf("newGameState: %v", err)
+	}
+	b, err := newGameState(rules, "g1", players, 42)
+	if err != nil {
+		t.Fatalf("newGameState: %v", err)
+	}
+	if !reflect.DeepEqual(a.Roles, b.Roles) {
+		t.Errorf("expected identical deals for the same seed, got %v and %v", a.Roles, b.Roles)
+	}
+}
@@ -0,0 +1,193 @@
+package games
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Alignments.
+const (
+	AlignmentGood = "good"
+	AlignmentEvil = "evil"
+)
+
+// Role names (stored in GameState.Roles and game_players.role).
+const (
+	RoleMerlin       = "merlin"
+	RolePercival     = "percival"
+	RoleLoyalServant = "loyal_servant"
+	RoleAssassin     = "assassin"
+	RoleMorgana      = "morgana"
+	RoleMordred      = "mordred"
+	RoleOberon       = "oberon"
+	RoleMinion       = "minion"
+)
+
+// Knowledge labels: what a player is shown about another player at night.
+const (
+	KnownAsEvil            = "evil"
+	KnownAsMerlinOrMorgana = "merlin_or_morgana"
+)
+
+// RoleDef describes a role: its alignment and what it can see during the night phase.
+type RoleDef struct {
+	Name      string `json:"name"`
+	Alignment string `json:"alignment"`
+	// Special roles may appear at most once; generic roles (loyal servant, minion) fill the remaining seats.
+	Special bool `json:"special"`
+	// SeesEvil: sees every evil player except those HiddenFromMerlin (Merlin).
+	SeesEvil bool `json:"sees_evil"`
+	// SeesEvilTeammates: sees other evil players except those HiddenFromEvil.
+	SeesEvilTeammates bool `json:"sees_evil_teammates"`
+	// SeesMerlinCandidates: sees players who AppearAsMerlin without knowing which is which (Percival).
+	SeesMerlinCandidates bool `json:"sees_merlin_candidates"`
+	// HiddenFromMerlin: not revealed to SeesEvil roles (Mordred).
+	HiddenFromMerlin bool `json:"hidden_from_merlin"`
+	// HiddenFromEvil: not revealed to, and does not see, other evil players (Oberon).
+	HiddenFromEvil bool `json:"hidden_from_evil"`
+	// AppearsAsMerlin: shown to SeesMerlinCandidates roles (Merlin, Morgana).
+	AppearsAsMerlin bool `json:"appears_as_merlin"`
+}
+
+// RoleCatalog holds every role the host may pick from, keyed by role name.
+var RoleCatalog = map[string]RoleDef{
+	RoleMerlin:       {Name: RoleMerlin, Alignment: AlignmentGood, Special: true, SeesEvil: true, AppearsAsMerlin: true},
+	RolePercival:     {Name: RolePercival, Alignment: AlignmentGood, Special: true, SeesMerlinCandidates: true},
+	RoleLoyalServant: {Name: RoleLoyalServant, Alignment: AlignmentGood},
+	RoleAssassin:     {Name: RoleAssassin, Alignment: AlignmentEvil, Special: true, SeesEvilTeammates: true},
+	RoleMorgana:      {Name: RoleMorgana, Alignment: AlignmentEvil, Special: true, SeesEvilTeammates: true, AppearsAsMerlin: true},
+	RoleMordred:      {Name: RoleMordred, Alignment: AlignmentEvil, Special: true, SeesEvilTeammates: true, HiddenFromMerlin: true},
+	RoleOberon:       {Name: RoleOberon, Alignment: AlignmentEvil, Special: true, HiddenFromEvil: true},
+	RoleMinion:       {Name: RoleMinion, Alignment: AlignmentEvil, SeesEvilTeammates: true},
+}
+
+// EvilCountForPlayerCount returns the number of evil seats for 5–10 players (classic Avalon).
+func EvilCountForPlayerCount(n int) int {
+	switch {
+	case n >= 10:
+		return 4
+	case n >= 7:
+		return 3
+	default:
+		return 2
+	}
+}
+
+// AlignmentOf returns the alignment of a role name ("good" | "evil"), or "" if the role is unknown.
+// Bare "good" / "evil" values (older snapshots) map to themselves.
+func AlignmentOf(role string) string {
+	if def, ok := RoleCatalog[role]; ok {
+		return def.Alignment
+	}
+	if role == AlignmentGood || role == AlignmentEvil {
+		return role
+	}
+	return ""
+}
+
+// ValidateRoleSet checks that the chosen special roles are legal for n players:
+// valid names (see validateRoleNames) and within the good/evil seat counts.
+func ValidateRoleSet(roles []string, n int) error {
+	if err := validateRoleNames(roles); err != nil {
+		return err
+	}
+	evilSeats := EvilCountForPlayerCount(n)
+	goodSeats := n - evilSeats
+	good, evil := 0, 0
+	for _, r := range roles {
+		if RoleCatalog[r].Alignment == AlignmentEvil {
+			evil++
+		} else {
+			good++
+		}
+	}
+	if evil > evilSeats {
+		return fmt.Errorf("%d evil roles chosen but only %d evil seats for %d players", evil, evilSeats, n)
+	}
+	if good > goodSeats {
+		return fmt.Errorf("%d good roles chosen but only %d good seats for %d players", good, goodSeats, n)
+	}
+	return nil
+}
+
+// validateRoleNames checks the parts of a role set that do not depend on player count:
+// known, special, not repeated, and with their dependencies present.
+func validateRoleNames(roles []string) error {
+	seen := make(map[string]bool, len(roles))
+	for _, r := range roles {
+		def, ok := RoleCatalog[r]
+		if !ok {
+			return fmt.Errorf("unknown role %q", r)
+		}
+		if !def.Special {
+			return fmt.Errorf("role %q is assigned automatically and cannot be chosen", r)
+		}
+		if seen[r] {
+			return fmt.Errorf("role %q chosen more than once", r)
+		}
+		seen[r] = true
+	}
+	for _, r := range []string{RolePercival, RoleMorgana, RoleAssassin} {
+		if seen[r] && !seen[RoleMerlin] {
+			return fmt.Errorf("role %q requires merlin", r)
+		}
+	}
+	return nil
+}
+
+// AssignRoles deals the chosen special roles plus loyal servants and minions to playerIDs using rng.
+// Returns map room_player_id -> role name.
+func AssignRoles(playerIDs []string, roles []string, rng *rand.Rand) (map[string]string, error) {
+	n := len(playerIDs)
+	if err := ValidateRoleSet(roles, n); err != nil {
+		return nil, err
+	}
+	evilSeats := EvilCountForPlayerCount(n)
+	deck := make([]string, 0, n)
+	evil := 0
+	for _, r := range roles {
+		deck = append(deck, r)
+		if RoleCatalog[r].Alignment == AlignmentEvil {
+			evil++
+		}
+	}
+	for ; evil < evilSeats; evil++ {
+		deck = append(deck, RoleMinion)
+	}
+	for len(deck) < n {
+		deck = append(deck, RoleLoyalServant)
+	}
+	out := make(map[string]string, n)
+	for i, j := range rng.Perm(n) {
+		out[playerIDs[i]] = deck[j]
+	}
+	return out, nil
+}
+
+// RoleKnowledge returns what viewerID's role reveals at night: other room_player_id -> knowledge label
+// ("evil" or "merlin_or_morgana"). Never includes the viewer or anyone's exact role.
+func RoleKnowledge(roles map[string]string, viewerID string) map[string]string {
+	viewer, ok := RoleCatalog[roles[viewerID]]
+	if !ok {
+		return map[string]string{}
+	}
+	out := make(map[string]string)
+	for id, role := range roles {
+		if id == viewerID {
+			continue
+		}
+		def, ok := RoleCatalog[role]
+		if !ok {
+			continue
+		}
+		switch {
+		case viewer.SeesEvil && def.Alignment == AlignmentEvil && !def.HiddenFromMerlin:
+			out[id] = KnownAsEvil
+		case viewer.SeesEvilTeammates && !viewer.HiddenFromEvil && def.Alignment == AlignmentEvil && !def.HiddenFromEvil:
+			out[id] = KnownAsEvil
+		case viewer.SeesMerlinCandidates && def.AppearsAsMerlin:
+			out[id] = KnownAsMerlinOrMorgana
+		}
+	}
+	return out
+}
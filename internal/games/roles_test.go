@@ -0,0 +1,74 @@
+package games
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestValidateRoleSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		roles   []string
+		n       int
+		wantErr bool
+	}{
+		{"classic", []string{RoleMerlin, RoleAssassin}, 5, false},
+		{"full 10", []string{RoleMerlin, RolePercival, RoleAssassin, RoleMorgana, RoleMordred, RoleOberon}, 10, false},
+		{"unknown role", []string{"jester"}, 5, true},
+		{"generic role", []string{RoleMinion}, 5, true},
+		{"duplicate", []string{RoleMerlin, RoleMerlin}, 5, true},
+		{"too many evil", []string{RoleMerlin, RoleAssassin, RoleMorgana, RoleMordred}, 5, true},
+		{"percival without merlin", []string{RolePercival}, 5, true},
+	}
+	for _, tt := range tests {
+		err := ValidateRoleSet(tt.roles, tt.n)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got err %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestAssignRoles_SeatCounts(t *testing.T) {
+	players := []string{"p1", "p2", "p3", "p4", "p5", "p6", "p7", "p8", "p9", "p10"}
+	roles, err := AssignRoles(players, []string{RoleMerlin, RoleAssassin}, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatalf("AssignRoles: %v", err)
+	}
+	evil := 0
+	for _, id := range players {
+		if AlignmentOf(roles[id]) == AlignmentEvil {
+			evil++
+		}
+	}
+	if evil != 4 {
+		t.Errorf("expected 4 evil players for 10, got %d", evil)
+	}
+}
+
+func TestRoleKnowledge(t *testing.T) {
+	roles := map[string]string{
+		"merlin": RoleMerlin, "percival": RolePercival, "servant": RoleLoyalServant,
+		"assassin": RoleAssassin, "morgana": RoleMorgana, "mordred": RoleMordred, "oberon": RoleOberon,
+	}
+	merlin := RoleKnowledge(roles, "merlin")
+	if merlin["assassin"] != KnownAsEvil || merlin["oberon"] != KnownAsEvil {
+		t.Errorf("merlin should see assassin and oberon, got %v", merlin)
+	}
+	if _, ok := merlin["mordred"]; ok {
+		t.Error("merlin should not see mordred")
+	}
+	percival := RoleKnowledge(roles, "percival")
+	if len(percival) != 2 || percival["merlin"] != KnownAsMerlinOrMorgana || percival["morgana"] != KnownAsMerlinOrMorgana {
+		t.Errorf("percival should see merlin and morgana only, got %v", percival)
+	}
+	assassin := RoleKnowledge(roles, "assassin")
+	if _, ok := assassin["oberon"]; ok {
+		t.Error("assassin should not see oberon")
+	}
+	if assassin["mordred"] != KnownAsEvil || assassin["morgana"] != KnownAsEvil {
+		t.Errorf("assassin should see mordred and morgana, got %v", assassin)
+	}
+	if len(RoleKnowledge(roles, "oberon")) != 0 || len(RoleKnowledge(roles, "servant")) != 0 {
+		t.Error("oberon and loyal servant should see nobody")
+	}
+}
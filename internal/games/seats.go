@@ -0,0 +1,218 @@
+package games
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/vntrieu/avalon/internal/store"
+)
+
+// ReconnectGracePeriod is how long a disconnected player has to come back before the host may replace or flag their seat.
+const ReconnectGracePeriod = 60 * time.Second
+
+// MoveTypeConnection is the game_events type (and move_type) recording a seated player's connection closing or coming back.
+const MoveTypeConnection = "connection"
+
+// SetPlayerConnected records that roomPlayerID's last connection closed (connected false) or that they are back.
+// Only seated players of a started, unfinished game are tracked. Reconnecting clears a flag_seat flag.
+// Returns an empty result when nothing changes.
+func (e *Engine) SetPlayerConnected(ctx context.Context, gameID string, roomPlayerID string, connected bool) ApplyMoveResult {
+	var result ApplyMoveResult
+	for attempt := 0; attempt < maxMoveAttempts; attempt++ {
+		result = e.setPlayerConnectedOnce(ctx, gameID, roomPlayerID, connected)
+		if !errors.Is(result.Error, store.ErrStaleState) {
+			return result
+		}
+	}
+	return ApplyMoveResult{Error: fmt.Errorf("%w: connection change kept losing to concurrent moves", store.ErrStaleState)}
+}
+
+func (e *Engine) setPlayerConnectedOnce(ctx context.Context, gameID string, roomPlayerID string, connected bool) ApplyMoveResult {
+	state, err := e.GetState(ctx, gameID)
+	if err != nil {
+		return ApplyMoveResult{Error: fmt.Errorf("get state: %w", err)}
+	}
+	if state == nil || len(state.PlayerIDs) == 0 || state.Status == "finished" || !e.isPlayerInGame(state, roomPlayerID) {
+		return ApplyMoveResult{}
+	}
+	if _, disconnected := state.Disconnected[roomPlayerID]; connected != disconnected {
+		return ApplyMoveResult{}
+	}
+	rules, err := e.rulesFor(ctx, gameID)
+	if err != nil {
+		return ApplyMoveResult{Error: err}
+	}
+	payload := map[string]interface{}{"connected": connected, "at": e.now().UTC().Format(time.RFC3339)}
+	next, events, err := applyConnection(state, roomPlayerID, payload)
+	if err != nil {
+		return ApplyMoveResult{Error: err}
+	}
+	return e.commitMove(ctx, rules, gameID, &roomPlayerID, MoveTypeConnection, payload, state, next, events)
+}
+
+// applyConnection applies a connection move: payload "connected" (bool) and "at" (RFC 3339 time of the change).
+func applyConnection(state *GameState, roomPlayerID string, payload map[string]interface{}) (*GameState, []BroadcastEvent, error) {
+	connected, ok := payload["connected"].(bool)
+	if !ok {
+		return nil, nil, fmt.Errorf("connection payload must include connected: true/false")
+	}
+	next := state.Clone()
+	if connected {
+		delete(next.Disconnected, roomPlayerID)
+		next.AutoSeats = removeString(next.AutoSeats, roomPlayerID)
+		ev := BroadcastEvent{Event: "player_reconnected", Payload: map[string]interface{}{"player_id": roomPlayerID}}
+		return next, []BroadcastEvent{ev}, nil
+	}
+	at, _ := payload["at"].(string)
+	if next.Disconnected == nil {
+		next.Disconnected = make(map[string]string)
+	}
+	next.Disconnected[roomPlayerID] = at
+	ev := BroadcastEvent{Event: "player_disconnected", Payload: map[string]interface{}{
+		"player_id": roomPlayerID, "since": at, "grace_seconds": int(ReconnectGracePeriod / time.Second)}}
+	return next, []BroadcastEvent{ev}, nil
+}
+
+// checkSeatAction checks what a seat action needs beyond the state itself: the seat's player has been gone
+// for ReconnectGracePeriod, and a replacement is a member of the game's room. A spectator (replacement_id is
+// their spectator ID) joins the room as a player first: payload's replacement_id becomes their room_player_id
+// and spectator_id records who they were.
+func (e *Engine) checkSeatAction(ctx context.Context, gameID string, state *GameState, action string, payload map[string]interface{}) error {
+	seat, _ := payload["seat_id"].(string)
+	since, ok := state.Disconnected[seat]
+	if !ok {
+		// applySeatAction reports the precise error.
+		return nil
+	}
+	if t, err := time.Parse(time.RFC3339, since); err == nil {
+		if left := ReconnectGracePeriod - e.now().Sub(t); left > 0 {
+			seconds := int(math.Ceil(left.Seconds()))
+			return moveError(CodeReconnectPending, map[string]interface{}{"seat_id": seat, "seconds_left": seconds}, "player has %d more seconds to reconnect", seconds)
+		}
+	}
+	if action != ActionReplacePlayer {
+		return nil
+	}
+	replacement, _ := payload["replacement_id"].(string)
+	if replacement == "" {
+		return nil
+	}
+	member, err := e.store.IsGameRoomMember(ctx, gameID, replacement)
+	if err != nil {
+		return fmt.Errorf("check room member: %w", err)
+	}
+	if member {
+		return nil
+	}
+	roomPlayerID, err := e.store.SeatSpectator(ctx, gameID, replacement)
+	if errors.Is(err, store.ErrDisplayNameTaken) {
+		return moveError(CodeInvalidReplacement, map[string]interface{}{"replacement_id": replacement}, "replacement's display name is taken in this room")
+	}
+	if err != nil {
+		return fmt.Errorf("seat spectator: %w", err)
+	}
+	if roomPlayerID == "" {
+		return moveError(CodeInvalidReplacement, map[string]interface{}{"replacement_id": replacement}, "replacement is not in this room")
+	}
+	payload["replacement_id"] = roomPlayerID
+	payload["spectator_id"] = replacement
+	return nil
+}
+
+// applySeatAction hands a disconnected player's seat to replacement_id (replace_player), who keeps the seat's role,
+// or flags it so the server plays the default move for it (flag_seat). payload "seat_id" is the seated room_player_id.
+func (e *Engine) applySeatAction(state *GameState, roomPlayerID string, action string, payload map[string]interface{}) (*GameState, []BroadcastEvent, error) {
+	seat, _ := payload["seat_id"].(string)
+	if seat == "" {
+		return nil, nil, missingField("seat_id", "payload must include seat_id (room_player_id)")
+	}
+	if !e.isPlayerInGame(state, seat) {
+		return nil, nil, moveError(CodeInvalidTarget, map[string]interface{}{"seat_id": seat}, "seat_id is not a player in this game")
+	}
+	if _, ok := state.Disconnected[seat]; !ok {
+		return nil, nil, moveError(CodePlayerConnected, map[string]interface{}{"seat_id": seat}, "player is still connected")
+	}
+
+	switch action {
+	case ActionFlagSeat:
+		if state.IsAutoSeat(seat) {
+			return nil, nil, moveError(CodeSeatFlagged, map[string]interface{}{"seat_id": seat}, "seat is already flagged")
+		}
+		next := state.Clone()
+		next.AutoSeats = append(next.AutoSeats, seat)
+		ev := BroadcastEvent{Event: "seat_flagged", Payload: map[string]interface{}{"seat_id": seat, "flagged_by": roomPlayerID}}
+		return next, []BroadcastEvent{ev}, nil
+	case ActionReplacePlayer:
+		replacement, _ := payload["replacement_id"].(string)
+		if replacement == "" {
+			return nil, nil, missingField("replacement_id", "payload must include replacement_id (room_player_id)")
+		}
+		if e.isPlayerInGame(state, replacement) {
+			return nil, nil, moveError(CodeInvalidReplacement, map[string]interface{}{"replacement_id": replacement}, "replacement already has a seat")
+		}
+		next := state.Clone()
+		next.replaceSeat(seat, replacement)
+		public := BroadcastEvent{Event: "seat_replaced", Payload: map[string]interface{}{
+			"seat_id": seat, "replacement_id": replacement, "replaced_by": roomPlayerID}}
+		if spectator, _ := payload["spectator_id"].(string); spectator != "" {
+			public.Payload["spectator_id"] = spectator
+		}
+		// The new player learns the seat's role as if dealt at the start.
+		private := BroadcastEvent{Event: "role_assigned", To: replacement, Payload: RoleBriefing(next, replacement)}
+		return next, []BroadcastEvent{public, private}, nil
+	}
+	return nil, nil, moveError(CodeUnknownMove, map[string]interface{}{"action": action}, "action %q is not a seat action", action)
+}
+
+// replaceSeat gives from's seat to to: turn order, role, team, votes and Lady of the Lake history.
+func (s *GameState) replaceSeat(from, to string) {
+	swap := func(id string) string {
+		if id == from {
+			return to
+		}
+		return id
+	}
+	for i, id := range s.PlayerIDs {
+		s.PlayerIDs[i] = swap(id)
+	}
+	for i, id := range s.ProposedTeam {
+		s.ProposedTeam[i] = swap(id)
+	}
+	for i, id := range s.LadyHolders {
+		s.LadyHolders[i] = swap(id)
+	}
+	s.LadyHolder = swap(s.LadyHolder)
+	s.AssassinTarget = swap(s.AssassinTarget)
+	for _, m := range []map[string]string{s.Roles, s.TeamVotes, s.MissionVotes} {
+		if v, ok := m[from]; ok {
+			delete(m, from)
+			m[to] = v
+		}
+	}
+	delete(s.Disconnected, from)
+	s.AutoSeats = removeString(s.AutoSeats, from)
+}
+
+// seatChanges lists the seats that changed hands between prev and next, for the game_players rows.
+func seatChanges(prev, next *GameState) []store.SeatChange {
+	var out []store.SeatChange
+	for i, id := range prev.PlayerIDs {
+		if i < len(next.PlayerIDs) && next.PlayerIDs[i] != id {
+			out = append(out, store.SeatChange{From: id, To: next.PlayerIDs[i], Role: next.Roles[next.PlayerIDs[i]]})
+		}
+	}
+	return out
+}
+
+func removeString(list []string, s string) []string {
+	var out []string
+	for _, v := range list {
+		if v != s {
+			out = append(out, v)
+		}
+	}
+	return out
+}
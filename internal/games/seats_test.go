@@ -0,0 +1,171 @@
+package games
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/vntrieu/avalon/internal/store"
+)
+
+func TestSetPlayerConnected(t *testing.T) {
+	players := []string{"p1", "p2", "p3", "p4", "p5"}
+	st := &fakeGameStore{players: players}
+	engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	engine.SetClock(func() time.Time { return now })
+	ctx := context.Background()
+
+	if r := engine.SetPlayerConnected(ctx, "g1", "p2", false); r.Error != nil || r.State != nil {
+		t.Errorf("expected nothing to track before start, got %+v", r)
+	}
+	if r := engine.ApplyMove(ctx, "g1", "p1", "action", map[string]interface{}{"action": "start_game"}); r.Error != nil {
+		t.Fatalf("start_game: %v", r.Error)
+	}
+	result := engine.SetPlayerConnected(ctx, "g1", "p2", false)
+	if result.Error != nil {
+		t.Fatalf("disconnect: %v", result.Error)
+	}
+	if result.State.Disconnected["p2"] != "2025-03-01T12:00:00Z" || result.Events[0].Event != "player_disconnected" {
+		t.Errorf("expected p2 disconnected at 12:00, got %v / %v", result.State.Disconnected, result.Events)
+	}
+	if r := engine.SetPlayerConnected(ctx, "g1", "p2", false); r.State != nil {
+		t.Error("expected a second disconnect to change nothing")
+	}
+	if r := engine.SetPlayerConnected(ctx, "g1", "spectator", false); r.State != nil {
+		t.Error("expected non-players to be ignored")
+	}
+	result = engine.SetPlayerConnected(ctx, "g1", "p2", true)
+	if result.Error != nil || len(result.State.Disconnected) != 0 || result.Events[0].Event != "player_reconnected" {
+		t.Errorf("expected p2 back, got %+v", result)
+	}
+}
+
+func TestApplyMove_ReplacePlayer(t *testing.T) {
+	players := []string{"p1", "p2", "p3", "p4", "p5"}
+	state := &GameState{
+		GameID: "g1", Phase: PhaseTeamVote, Status: "in_progress", RoundIndex: 1, LeaderIndex: 1,
+		PlayerIDs: players, ProposedTeam: []string{"p1", "p2"}, TeamVotes: map[string]string{"p2": "approve"},
+		Roles:        map[string]string{"p1": RoleMerlin, "p2": RoleAssassin, "p3": RoleLoyalServant, "p4": RoleLoyalServant, "p5": RoleMinion},
+		Disconnected: map[string]string{"p2": "2025-03-01T12:00:00Z"},
+	}
+	st := &fakeGameStore{snapshot: snapshotOf(t, state), players: players, host: "p1", members: []string{"s1"}}
+	engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	now := time.Date(2025, 3, 1, 12, 0, 30, 0, time.UTC)
+	engine.SetClock(func() time.Time { return now })
+	ctx := context.Background()
+	replace := map[string]interface{}{"action": "replace_player", "seat_id": "p2", "replacement_id": "s1"}
+
+	if r := engine.ApplyMove(ctx, "g1", "p1", "action", replace); r.Error == nil {
+		t.Error("expected error within the grace period")
+	}
+	now = now.Add(ReconnectGracePeriod)
+	if r := engine.ApplyMove(ctx, "g1", "p3", "action", replace); r.Error == nil {
+		t.Error("expected error when a non-host replaces a player")
+	}
+	if r := engine.ApplyMove(ctx, "g1", "p1", "action", map[string]interface{}{"action": "replace_player", "seat_id": "p3", "replacement_id": "s1"}); r.Error == nil {
+		t.Error("expected error when the seat's player is connected")
+	}
+	if r := engine.ApplyMove(ctx, "g1", "p1", "action", map[string]interface{}{"action": "replace_player", "seat_id": "p2", "replacement_id": "stranger"}); r.Error == nil {
+		t.Error("expected error when the replacement is not in the room")
+	}
+	result := engine.ApplyMove(ctx, "g1", "p1", "action", replace)
+	if result.Error != nil {
+		t.Fatalf("replace_player: %v", result.Error)
+	}
+	next := result.State
+	if !reflect.DeepEqual(next.PlayerIDs, []string{"p1", "s1", "p3", "p4", "p5"}) || next.Roles["s1"] != RoleAssassin || next.LeaderPlayerID() != "s1" {
+		t.Errorf("expected s1 to take p2's seat, role and leadership, got %v %v", next.PlayerIDs, next.Roles)
+	}
+	if next.TeamVotes["s1"] != "approve" || next.ProposedTeam[1] != "s1" || len(next.Disconnected) != 0 {
+		t.Errorf("expected p2's vote and team slot to move to s1, got %+v", next)
+	}
+	if len(result.Events) != 2 || result.Events[1].Event != "role_assigned" || result.Events[1].To != "s1" || result.Events[1].Payload["role"] != RoleAssassin {
+		t.Errorf("expected a private role briefing for s1, got %v", result.Events)
+	}
+	want := []store.SeatChange{{From: "p2", To: "s1", Role: RoleAssassin}}
+	if !reflect.DeepEqual(st.seats, want) {
+		t.Errorf("expected seat change %v committed, got %v", want, st.seats)
+	}
+	if r := engine.ApplyMove(ctx, "g1", "s1", "vote", map[string]interface{}{"approved": true}); r.Error == nil {
+		t.Error("expected the replacement to keep p2's vote")
+	}
+}
+
+func TestApplyMove_ReplacePlayerWithSpectator(t *testing.T) {
+	players := []string{"p1", "p2", "p3", "p4", "p5"}
+	state := &GameState{
+		GameID: "g1", Phase: PhaseTeamSelection, Status: "in_progress", RoundIndex: 1,
+		PlayerIDs:    players,
+		Roles:        map[string]string{"p1": RoleMerlin, "p2": RoleAssassin, "p3": RoleLoyalServant, "p4": RoleLoyalServant, "p5": RoleMinion},
+		Disconnected: map[string]string{"p2": "2025-03-01T12:00:00Z"},
+	}
+	st := &fakeGameStore{snapshot: snapshotOf(t, state), players: players, host: "p1", spectators: map[string]string{"u1": "rp-u1"}}
+	engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	engine.SetClock(func() time.Time { return time.Date(2025, 3, 1, 12, 5, 0, 0, time.UTC) })
+	ctx := context.Background()
+
+	result := engine.ApplyMove(ctx, "g1", "p1", "action", map[string]interface{}{"action": "replace_player", "seat_id": "p2", "replacement_id": "u1"})
+	if result.Error != nil {
+		t.Fatalf("replace_player: %v", result.Error)
+	}
+	if result.State.PlayerIDs[1] != "rp-u1" || result.State.Roles["rp-u1"] != RoleAssassin {
+		t.Errorf("expected the spectator's new room player to take p2's seat, got %v %v", result.State.PlayerIDs, result.State.Roles)
+	}
+	if p := result.Events[0].Payload; p["replacement_id"] != "rp-u1" || p["spectator_id"] != "u1" {
+		t.Errorf("expected seat_replaced to name the room player and the spectator, got %v", p)
+	}
+	if logged := st.events[len(st.events)-1].Payload; logged["replacement_id"] != "rp-u1" {
+		t.Errorf("expected the logged move to name the room player, got %v", logged)
+	}
+}
+
+func TestApplyMove_FlagSeat(t *testing.T) {
+	players := []string{"p1", "p2", "p3", "p4", "p5"}
+	state := &GameState{
+		GameID: "g1", Phase: PhaseTeamVote, Status: "in_progress", RoundIndex: 1,
+		PlayerIDs: players, ProposedTeam: []string{"p1", "p2"},
+		TeamVotes:    map[string]string{"p1": "approve", "p3": "approve", "p4": "reject"},
+		Roles:        map[string]string{"p1": RoleMerlin, "p2": RoleAssassin, "p3": RoleLoyalServant, "p4": RoleLoyalServant, "p5": RoleMinion},
+		Disconnected: map[string]string{"p2": "2025-03-01T12:00:00Z"},
+	}
+	st := &fakeGameStore{snapshot: snapshotOf(t, state), players: players, host: "p1"}
+	engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	now := time.Date(2025, 3, 1, 12, 5, 0, 0, time.UTC)
+	engine.SetClock(func() time.Time { return now })
+	ctx := context.Background()
+
+	result := engine.ApplyMove(ctx, "g1", "p1", "action", map[string]interface{}{"action": "flag_seat", "seat_id": "p2"})
+	if result.Error != nil {
+		t.Fatalf("flag_seat: %v", result.Error)
+	}
+	if !result.State.IsAutoSeat("p2") || result.State.Deadline != "" {
+		t.Errorf("expected p2 flagged and the vote still waiting on p5, got %v / %q", result.State.AutoSeats, result.State.Deadline)
+	}
+
+	// Once only the flagged seat is missing, the turn is due at once and the timer votes for it.
+	result = engine.ApplyMove(ctx, "g1", "p5", "vote", map[string]interface{}{"approved": false})
+	if result.Error != nil {
+		t.Fatalf("vote: %v", result.Error)
+	}
+	if result.State.Deadline != "2025-03-01T12:05:00Z" {
+		t.Errorf("expected the deadline to be now, got %q", result.State.Deadline)
+	}
+	result = engine.ApplyTimeout(ctx, "g1")
+	if result.Error != nil || result.State == nil {
+		t.Fatalf("ApplyTimeout: %+v", result)
+	}
+	if got := result.Events[0].Payload["defaulted"]; !reflect.DeepEqual(got, []string{"p2"}) || result.State.Phase != PhaseMissionVote {
+		t.Errorf("expected p2's approve to send the team, got %v / %s", got, result.State.Phase)
+	}
+
+	// p2 is on the team: the mission card is played for them too, and reconnecting clears the flag.
+	if !waitingOnAutoSeats(&GameState{Phase: PhaseMissionVote, ProposedTeam: []string{"p1", "p2"},
+		MissionVotes: map[string]string{"p1": "success"}, AutoSeats: []string{"p2"}}) {
+		t.Error("expected the mission to wait only on the flagged seat")
+	}
+	if r := engine.SetPlayerConnected(ctx, "g1", "p2", true); r.Error != nil || r.State.IsAutoSeat("p2") {
+		t.Errorf("expected reconnecting to clear the flag, got %+v", r)
+	}
+}
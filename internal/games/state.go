@@ -1,15 +1,22 @@
 package games
 
+import (
+	"strconv"
+	"time"
+)
+
 // GameState is the full engine state, serialized to JSON for snapshots.
 type GameState struct {
-	GameID    string   `json:"game_id"`
-	Phase     string   `json:"phase"`
-	Status    string   `json:"status"` // waiting | in_progress | finished
-	RoundIndex int     `json:"round_index"` // 1-based mission round
-	LeaderIndex int    `json:"leader_index"` // index into PlayerIDs
-	PlayerIDs  []string `json:"player_ids"`  // room_player_id in order (determines leader rotation)
+	GameID      string   `json:"game_id"`
+	Phase       string   `json:"phase"`
+	Status      string   `json:"status"`       // waiting | in_progress | paused | finished
+	RoundIndex  int      `json:"round_index"`  // 1-based mission round
+	LeaderIndex int      `json:"leader_index"` // index into PlayerIDs
+	PlayerIDs   []string `json:"player_ids"`   // room_player_id in order (determines leader rotation)
 	// Roles: map room_player_id -> role (e.g. "good", "evil", "merlin"). Omitted until game end or per rules.
 	Roles map[string]string `json:"roles,omitempty"`
+	// TeamSizes: required team size per round (1-based round index), fixed when the game starts.
+	TeamSizes []int `json:"team_sizes,omitempty"`
 	// ProposedTeam is set during team_selection/team_vote (the current proposal).
 	ProposedTeam []string `json:"proposed_team,omitempty"`
 	// TeamVotes: for team_vote phase, map room_player_id -> "approve" | "reject"
@@ -20,8 +27,28 @@ type GameState struct {
 	MissionResults []string `json:"mission_results,omitempty"`
 	// RejectCount: number of consecutive team rejections (resets when team approved).
 	RejectCount int `json:"reject_count,omitempty"`
+	// LadyHolder: room_player_id holding the Lady of the Lake token (only with the module enabled).
+	LadyHolder string `json:"lady_holder,omitempty"`
+	// LadyHolders: everyone who has held the token, in order; they cannot be inspected.
+	LadyHolders []string `json:"lady_holders,omitempty"`
+	// Seed: role-deal seed. Secret until the game is finished (see PlayerView); stored as a decimal string.
+	Seed int64 `json:"seed,omitempty,string"`
+	// SeedHash: hex SHA-256 of the seed, public from the start (see SeedHash).
+	SeedHash string `json:"seed_hash,omitempty"`
+	// AssassinTarget: room_player_id named by the assassin in the assassination phase.
+	AssassinTarget string `json:"assassin_target,omitempty"`
+	// Deadline: RFC 3339 UTC time when the current phase's timer runs out ("" = no timer). See ApplyTimeout.
+	Deadline string `json:"deadline,omitempty"`
+	// Disconnected: room_player_id -> RFC 3339 time the player's last connection closed (only seated players).
+	Disconnected map[string]string `json:"disconnected,omitempty"`
+	// AutoSeats: seats flagged by the host after a disconnect; the server plays the default move for them at once.
+	AutoSeats []string `json:"auto_seats,omitempty"`
+	// PausedTimeLeft: seconds left on the phase timer when the game was paused; the deadline restarts from it on resume.
+	PausedTimeLeft int `json:"paused_time_left,omitempty"`
 	// Winner: "good" | "evil" when status == finished.
 	Winner string `json:"winner,omitempty"`
+	// EndReason: why a game finished without a winner (the host's abort reason).
+	EndReason string `json:"end_reason,omitempty"`
 	// Version is incremented on each snapshot write (optional, can be set by store).
 	Version int `json:"version,omitempty"`
 }
@@ -32,12 +59,20 @@ func (s *GameState) Clone() *GameState {
 		return nil
 	}
 	out := *s
+	if s.PlayerIDs != nil {
+		out.PlayerIDs = make([]string, len(s.PlayerIDs))
+		copy(out.PlayerIDs, s.PlayerIDs)
+	}
 	if s.Roles != nil {
 		out.Roles = make(map[string]string, len(s.Roles))
 		for k, v := range s.Roles {
 			out.Roles[k] = v
 		}
 	}
+	if s.TeamSizes != nil {
+		out.TeamSizes = make([]int, len(s.TeamSizes))
+		copy(out.TeamSizes, s.TeamSizes)
+	}
 	if s.ProposedTeam != nil {
 		out.ProposedTeam = make([]string, len(s.ProposedTeam))
 		copy(out.ProposedTeam, s.ProposedTeam)
@@ -58,6 +93,20 @@ func (s *GameState) Clone() *GameState {
 		out.MissionResults = make([]string, len(s.MissionResults))
 		copy(out.MissionResults, s.MissionResults)
 	}
+	if s.LadyHolders != nil {
+		out.LadyHolders = make([]string, len(s.LadyHolders))
+		copy(out.LadyHolders, s.LadyHolders)
+	}
+	if s.Disconnected != nil {
+		out.Disconnected = make(map[string]string, len(s.Disconnected))
+		for k, v := range s.Disconnected {
+			out.Disconnected[k] = v
+		}
+	}
+	if s.AutoSeats != nil {
+		out.AutoSeats = make([]string, len(s.AutoSeats))
+		copy(out.AutoSeats, s.AutoSeats)
+	}
 	return &out
 }
 
@@ -69,6 +118,48 @@ func (s *GameState) LeaderPlayerID() string {
 	return s.PlayerIDs[s.LeaderIndex]
 }
 
+// PlayerWithRole returns the room_player_id holding role, or "" if no player has it.
+func (s *GameState) PlayerWithRole(role string) string {
+	if s == nil {
+		return ""
+	}
+	for _, id := range s.PlayerIDs {
+		if s.Roles[id] == role {
+			return id
+		}
+	}
+	return ""
+}
+
+// HasHeldLady reports whether roomPlayerID has held the Lady of the Lake token.
+func (s *GameState) HasHeldLady(roomPlayerID string) bool {
+	for _, id := range s.LadyHolders {
+		if id == roomPlayerID {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAutoSeat reports whether the server plays for roomPlayerID's seat.
+func (s *GameState) IsAutoSeat(roomPlayerID string) bool {
+	for _, id := range s.AutoSeats {
+		if id == roomPlayerID {
+			return true
+		}
+	}
+	return false
+}
+
+// DeadlinePassed reports whether the current phase's timer has run out at now.
+func (s *GameState) DeadlinePassed(now time.Time) bool {
+	if s == nil || s.Deadline == "" {
+		return false
+	}
+	deadline, err := time.Parse(time.RFC3339, s.Deadline)
+	return err == nil && !now.Before(deadline)
+}
+
 // ToMap converts state to a map for JSON snapshot (engine uses this for persistence).
 func (s *GameState) ToMap() map[string]interface{} {
 	if s == nil {
@@ -87,6 +178,9 @@ func (s *GameState) ToMap() map[string]interface{} {
 	if len(s.Roles) > 0 {
 		m["roles"] = s.Roles
 	}
+	if len(s.TeamSizes) > 0 {
+		m["team_sizes"] = s.TeamSizes
+	}
 	if len(s.ProposedTeam) > 0 {
 		m["proposed_team"] = s.ProposedTeam
 	}
@@ -99,9 +193,39 @@ func (s *GameState) ToMap() map[string]interface{} {
 	if len(s.MissionResults) > 0 {
 		m["mission_results"] = s.MissionResults
 	}
+	if s.LadyHolder != "" {
+		m["lady_holder"] = s.LadyHolder
+	}
+	if len(s.LadyHolders) > 0 {
+		m["lady_holders"] = s.LadyHolders
+	}
+	if s.Seed != 0 {
+		m["seed"] = strconv.FormatInt(s.Seed, 10)
+	}
+	if s.SeedHash != "" {
+		m["seed_hash"] = s.SeedHash
+	}
+	if s.AssassinTarget != "" {
+		m["assassin_target"] = s.AssassinTarget
+	}
+	if s.Deadline != "" {
+		m["deadline"] = s.Deadline
+	}
+	if len(s.Disconnected) > 0 {
+		m["disconnected"] = s.Disconnected
+	}
+	if len(s.AutoSeats) > 0 {
+		m["auto_seats"] = s.AutoSeats
+	}
+	if s.PausedTimeLeft > 0 {
+		m["paused_time_left"] = s.PausedTimeLeft
+	}
 	if s.Winner != "" {
 		m["winner"] = s.Winner
 	}
+	if s.EndReason != "" {
+		m["end_reason"] = s.EndReason
+	}
 	return m
 }
 
@@ -132,6 +256,9 @@ func StateFromMap(m map[string]interface{}) *GameState {
 	if v, ok := stringMap(m["roles"]); ok {
 		s.Roles = v
 	}
+	if v, ok := intSlice(m["team_sizes"]); ok {
+		s.TeamSizes = v
+	}
 	if v, ok := stringSlice(m["proposed_team"]); ok {
 		s.ProposedTeam = v
 	}
@@ -147,9 +274,39 @@ func StateFromMap(m map[string]interface{}) *GameState {
 	if v, ok := floatToInt(m["reject_count"]); ok {
 		s.RejectCount = v
 	}
+	if v, ok := m["lady_holder"].(string); ok {
+		s.LadyHolder = v
+	}
+	if v, ok := stringSlice(m["lady_holders"]); ok {
+		s.LadyHolders = v
+	}
+	if v, ok := m["seed"].(string); ok {
+		s.Seed, _ = strconv.ParseInt(v, 10, 64)
+	}
+	if v, ok := m["seed_hash"].(string); ok {
+		s.SeedHash = v
+	}
+	if v, ok := m["assassin_target"].(string); ok {
+		s.AssassinTarget = v
+	}
+	if v, ok := m["deadline"].(string); ok {
+		s.Deadline = v
+	}
+	if v, ok := stringMap(m["disconnected"]); ok {
+		s.Disconnected = v
+	}
+	if v, ok := stringSlice(m["auto_seats"]); ok {
+		s.AutoSeats = v
+	}
+	if v, ok := floatToInt(m["paused_time_left"]); ok {
+		s.PausedTimeLeft = v
+	}
 	if v, ok := m["winner"].(string); ok {
 		s.Winner = v
 	}
+	if v, ok := m["end_reason"].(string); ok {
+		s.EndReason = v
+	}
 	if v, ok := floatToInt(m["version"]); ok {
 		s.Version = v
 	}
@@ -183,6 +340,22 @@ func stringSlice(a interface{}) ([]string, bool) {
 	return nil, false
 }
 
+func intSlice(a interface{}) ([]int, bool) {
+	switch v := a.(type) {
+	case []int:
+		return v, true
+	case []interface{}:
+		out := make([]int, 0, len(v))
+		for _, x := range v {
+			if n, ok := floatToInt(x); ok {
+				out = append(out, n)
+			}
+		}
+		return out, true
+	}
+	return nil, false
+}
+
 func stringMap(a interface{}) (map[string]string, bool) {
 	m, ok := a.(map[string]interface{})
 	if !ok {
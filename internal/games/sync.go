@@ -0,0 +1,62 @@
+package games
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// MaxSyncGap is the most versions a state delta may span; a client further behind gets the full state.
+const MaxSyncGap = 50
+
+// StateDelta is what changed in a player's view (see PlayerView) between two versions of a game's state.
+// Applying it to the view at FromVersion gives the view at Version: set every Changed key, delete every Removed key.
+type StateDelta struct {
+	FromVersion int                    `json:"from_version"`
+	Version     int                    `json:"version"`
+	Changed     map[string]interface{} `json:"changed"`
+	Removed     []string               `json:"removed"`
+}
+
+// SyncSince returns the changes to viewerID's view from the snapshot at version since to state, the game's
+// latest state. It returns nil when the client should take the full state instead: since is not a version of
+// this game, is ahead of state, or is more than MaxSyncGap versions behind.
+func (e *Engine) SyncSince(ctx context.Context, gameID string, state *GameState, viewerID string, since int) (*StateDelta, error) {
+	if state == nil || since <= 0 || since > state.Version || state.Version-since > MaxSyncGap {
+		return nil, nil
+	}
+	if since == state.Version {
+		return &StateDelta{FromVersion: since, Version: state.Version, Changed: map[string]interface{}{}, Removed: []string{}}, nil
+	}
+	m, err := e.store.GetSnapshotAtVersion(ctx, gameID, int32(since))
+	if err != nil {
+		return nil, fmt.Errorf("get snapshot at version %d: %w", since, err)
+	}
+	if m == nil {
+		return nil, nil
+	}
+	changed, removed := ViewDelta(StateFromMap(m), state, viewerID)
+	return &StateDelta{FromVersion: since, Version: state.Version, Changed: changed, Removed: removed}, nil
+}
+
+// ViewDelta compares viewerID's views of prev and next: the keys whose value is new or changed in next,
+// and the keys (sorted) that next's view no longer has.
+func ViewDelta(prev, next *GameState, viewerID string) (changed map[string]interface{}, removed []string) {
+	before := PlayerView(prev, viewerID)
+	after := PlayerView(next, viewerID)
+	changed = make(map[string]interface{})
+	for k, v := range after {
+		if old, ok := before[k]; !ok || !reflect.DeepEqual(old, v) {
+			changed[k] = v
+		}
+	}
+	removed = []string{}
+	for k := range before {
+		if _, ok := after[k]; !ok {
+			removed = append(removed, k)
+		}
+	}
+	sort.Strings(removed)
+	return changed, removed
+}
@@ -0,0 +1,70 @@
+package games
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSyncSince(t *testing.T) {
+	players := []string{"p1", "p2", "p3", "p4", "p5"}
+	st := &fakeGameStore{players: players}
+	engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	ctx := context.Background()
+
+	if r := engine.ApplyMove(ctx, "g1", "p1", "action", map[string]interface{}{"action": "start_game"}); r.Error != nil {
+		t.Fatalf("start_game: %v", r.Error)
+	}
+	if r := engine.ApplyMove(ctx, "g1", "p1", "action", map[string]interface{}{"action": "propose_team", "team_ids": []interface{}{"p1", "p2"}}); r.Error != nil {
+		t.Fatalf("propose_team: %v", r.Error)
+	}
+	result := engine.ApplyMove(ctx, "g1", "p2", "vote", map[string]interface{}{"approved": true})
+	if result.Error != nil {
+		t.Fatalf("vote: %v", result.Error)
+	}
+	state, err := engine.GetState(ctx, "g1")
+	if err != nil || state.Version != 3 {
+		t.Fatalf("expected version 3, got %v / %v", state, err)
+	}
+
+	delta, err := engine.SyncSince(ctx, "g1", state, "p2", 1)
+	if err != nil || delta == nil {
+		t.Fatalf("expected a delta from version 1, got %v / %v", delta, err)
+	}
+	if delta.FromVersion != 1 || delta.Version != 3 || delta.Changed["phase"] != PhaseTeamVote || delta.Changed["my_team_vote"] != "approve" {
+		t.Errorf("expected the team vote phase and p2's vote, got %+v", delta)
+	}
+	if _, ok := delta.Changed["my_role"]; ok {
+		t.Error("expected unchanged keys to be left out")
+	}
+
+	// Applying the delta to the old view gives the current view.
+	view := PlayerView(StateFromMap(st.history[1]), "p2")
+	for k, v := range delta.Changed {
+		view[k] = v
+	}
+	for _, k := range delta.Removed {
+		delete(view, k)
+	}
+	if want := PlayerView(state, "p2"); !reflect.DeepEqual(view, want) {
+		t.Errorf("expected patched view %v, got %v", want, view)
+	}
+
+	if delta, _ := engine.SyncSince(ctx, "g1", state, "p2", 3); delta == nil || len(delta.Changed) != 0 || len(delta.Removed) != 0 {
+		t.Errorf("expected an empty delta when up to date, got %+v", delta)
+	}
+	for _, since := range []int{0, 4} {
+		if delta, _ := engine.SyncSince(ctx, "g1", state, "p2", since); delta != nil {
+			t.Errorf("expected a full state for version %d, got %+v", since, delta)
+		}
+	}
+	far := state.Clone()
+	far.Version = 2 + MaxSyncGap
+	if delta, _ := engine.SyncSince(ctx, "g1", far, "p2", 1); delta != nil {
+		t.Errorf("expected a full state for a gap over MaxSyncGap, got %+v", delta)
+	}
+	delete(st.history, 2)
+	if delta, _ := engine.SyncSince(ctx, "g1", state, "p2", 2); delta != nil {
+		t.Errorf("expected a full state when the snapshot is gone, got %+v", delta)
+	}
+}
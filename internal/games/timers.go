@@ -0,0 +1,202 @@
+package games
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/vntrieu/avalon/internal/store"
+)
+
+// MoveTypeTimeout is the game_events type (and move_type) of a default move applied when a phase timer runs out.
+const MoveTypeTimeout = "timeout"
+
+// ApplyTimeout applies the default move for the game's current phase once its deadline has passed:
+// the leader's turn passes to the next player, missing team votes count as approve, missing mission cards
+// count as success, an unused Lady of the Lake is skipped, and an assassin who runs out of time loses.
+// The move is logged as a "timeout" event without a player. Returns an empty result if no timer has run out.
+func (e *Engine) ApplyTimeout(ctx context.Context, gameID string) ApplyMoveResult {
+	var result ApplyMoveResult
+	for attempt := 0; attempt < maxMoveAttempts; attempt++ {
+		result = e.applyTimeoutOnce(ctx, gameID)
+		if !errors.Is(result.Error, store.ErrStaleState) {
+			return result
+		}
+	}
+	return ApplyMoveResult{Error: fmt.Errorf("%w: timeout kept losing to concurrent moves", store.ErrStaleState)}
+}
+
+func (e *Engine) applyTimeoutOnce(ctx context.Context, gameID string) ApplyMoveResult {
+	state, err := e.GetState(ctx, gameID)
+	if err != nil {
+		return ApplyMoveResult{Error: fmt.Errorf("get state: %w", err)}
+	}
+	// A move made just before the deadline may have moved the game on; then there is nothing to do.
+	if state == nil || state.Status != "in_progress" || !state.DeadlinePassed(e.now()) {
+		return ApplyMoveResult{}
+	}
+	rules, err := e.rulesFor(ctx, gameID)
+	if err != nil {
+		return ApplyMoveResult{Error: err}
+	}
+	next, events, err := e.applyTimeout(ctx, rules, state)
+	if err != nil {
+		return ApplyMoveResult{Error: err}
+	}
+	payload := map[string]interface{}{"phase": state.Phase, "round_index": state.RoundIndex}
+	if len(events) > 0 {
+		payload["defaulted"] = events[0].Payload["defaulted"]
+	}
+	return e.commitMove(ctx, rules, gameID, nil, MoveTypeTimeout, payload, state, next, events)
+}
+
+// applyTimeout computes the default move for state's phase. The first event is always timer_expired,
+// listing the players the server acted for ("defaulted"), followed by the events of the default move.
+func (e *Engine) applyTimeout(ctx context.Context, rules RulesConfig, state *GameState) (*GameState, []BroadcastEvent, error) {
+	expired := BroadcastEvent{Event: "timer_expired", Payload: map[string]interface{}{
+		"phase": state.Phase, "round_index": state.RoundIndex}}
+
+	switch state.Phase {
+	case PhaseTeamSelection:
+		// The leader's turn passes; it does not count as a rejected team.
+		previous := state.LeaderPlayerID()
+		next := state.Clone()
+		next.LeaderIndex = (next.LeaderIndex + 1) % len(next.PlayerIDs)
+		next.ProposedTeam = nil
+		expired.Payload["defaulted"] = []string{previous}
+		ev := BroadcastEvent{Event: "turn_passed", Payload: map[string]interface{}{
+			"previous_leader_id": previous, "leader_id": next.LeaderPlayerID(), "phase": next.Phase}}
+		return next, []BroadcastEvent{expired, ev}, nil
+	case PhaseTeamVote:
+		return e.defaultVotes(ctx, rules, state, expired, state.PlayerIDs, state.TeamVotes, map[string]interface{}{"approved": true})
+	case PhaseMissionVote:
+		return e.defaultVotes(ctx, rules, state, expired, state.ProposedTeam, state.MissionVotes, map[string]interface{}{"success": true})
+	case PhaseLadyOfTheLake:
+		// The holder keeps the token and nobody is inspected this round.
+		next := state.Clone()
+		next.Phase = PhaseTeamSelection
+		expired.Payload["defaulted"] = []string{state.LadyHolder}
+		ev := BroadcastEvent{Event: "lady_skipped", Payload: map[string]interface{}{
+			"lady_holder": next.LadyHolder, "phase": next.Phase, "round_index": next.RoundIndex, "leader_id": next.LeaderPlayerID()}}
+		return next, []BroadcastEvent{expired, ev}, nil
+	case PhaseAssassination:
+		next := state.Clone()
+		next.Status = "finished"
+		next.Phase = PhaseFinished
+		next.Winner = "good"
+		expired.Payload["defaulted"] = []string{state.PlayerWithRole(RoleAssassin)}
+		ev := BroadcastEvent{Event: "game_ended", Payload: map[string]interface{}{
+			"winner": next.Winner, "reason": "assassination_timeout"}}
+		return next, []BroadcastEvent{expired, ev}, nil
+	}
+	return nil, nil, fmt.Errorf("no timer in phase %s", state.Phase)
+}
+
+// defaultVotes casts payload for every voter (in seat order) who has not voted yet and returns the
+// events of the last vote, which resolves the team vote or mission.
+func (e *Engine) defaultVotes(ctx context.Context, rules RulesConfig, state *GameState, expired BroadcastEvent, voters []string, cast map[string]string, payload map[string]interface{}) (*GameState, []BroadcastEvent, error) {
+	next := state
+	var events []BroadcastEvent
+	var defaulted []string
+	for _, id := range voters {
+		if _, ok := cast[id]; ok {
+			continue
+		}
+		var err error
+		next, events, err = e.applyVote(ctx, rules, next, id, payload)
+		if err != nil {
+			return nil, nil, err
+		}
+		defaulted = append(defaulted, id)
+	}
+	if len(defaulted) == 0 {
+		return nil, nil, fmt.Errorf("no missing votes in phase %s", state.Phase)
+	}
+	expired.Payload["defaulted"] = defaulted
+	return next, append([]BroadcastEvent{expired}, events...), nil
+}
+
+// scheduleDeadline sets next.Deadline: a new turn (phase, round or leader changed) gets a fresh deadline from
+// the phase's timer, otherwise the current deadline carries over. Pausing stops the clock, keeping the time left
+// in PausedTimeLeft, and resuming restarts it from there. Finished games and phases without a timer have none.
+// A turn that only waits on flagged seats (see ActionFlagSeat) is due at once.
+func scheduleDeadline(rules RulesConfig, prev, next *GameState, now time.Time) {
+	switch {
+	case next.Status == "finished":
+		next.Deadline, next.PausedTimeLeft = "", 0
+		return
+	case next.Status == "paused":
+		if prev != nil && prev.Status != "paused" {
+			next.PausedTimeLeft = secondsLeft(prev.Deadline, now)
+			next.Deadline = ""
+		}
+		return
+	case prev != nil && prev.Status == "paused":
+		next.Deadline = ""
+		if next.PausedTimeLeft > 0 {
+			next.Deadline = now.Add(time.Duration(next.PausedTimeLeft) * time.Second).UTC().Format(time.RFC3339)
+		}
+		next.PausedTimeLeft = 0
+	case prev != nil && prev.Phase == next.Phase && prev.RoundIndex == next.RoundIndex && prev.LeaderIndex == next.LeaderIndex &&
+		!(waitingOnAutoSeats(prev) && !waitingOnAutoSeats(next)):
+		next.Deadline = prev.Deadline
+	default:
+		next.Deadline = ""
+		if seconds := rules.Timers.SecondsFor(next.Phase); seconds > 0 {
+			next.Deadline = now.Add(time.Duration(seconds) * time.Second).UTC().Format(time.RFC3339)
+		}
+	}
+	if waitingOnAutoSeats(next) {
+		next.Deadline = now.UTC().Format(time.RFC3339)
+	}
+}
+
+// waitingOnAutoSeats reports whether every player the current turn waits for is a flagged seat.
+func waitingOnAutoSeats(s *GameState) bool {
+	if len(s.AutoSeats) == 0 {
+		return false
+	}
+	var pending []string
+	switch s.Phase {
+	case PhaseTeamSelection:
+		pending = []string{s.LeaderPlayerID()}
+	case PhaseTeamVote:
+		for _, id := range s.PlayerIDs {
+			if _, ok := s.TeamVotes[id]; !ok {
+				pending = append(pending, id)
+			}
+		}
+	case PhaseMissionVote:
+		for _, id := range s.ProposedTeam {
+			if _, ok := s.MissionVotes[id]; !ok {
+				pending = append(pending, id)
+			}
+		}
+	case PhaseLadyOfTheLake:
+		pending = []string{s.LadyHolder}
+	case PhaseAssassination:
+		pending = []string{s.PlayerWithRole(RoleAssassin)}
+	}
+	for _, id := range pending {
+		if !s.IsAutoSeat(id) {
+			return false
+		}
+	}
+	return len(pending) > 0
+}
+
+// secondsLeft returns the whole seconds from now until deadline, at least 1 for a deadline that has just passed,
+// or 0 if there is no deadline.
+func secondsLeft(deadline string, now time.Time) int {
+	t, err := time.Parse(time.RFC3339, deadline)
+	if deadline == "" || err != nil {
+		return 0
+	}
+	left := int(math.Ceil(t.Sub(now).Seconds()))
+	if left < 1 {
+		left = 1
+	}
+	return left
+}
@@ -0,0 +1,179 @@
+package games
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var timerConfig = map[string]interface{}{"timers": map[string]interface{}{
+	"proposal_seconds": float64(60), "vote_seconds": float64(30), "mission_seconds": float64(20)}}
+
+func TestApplyMove_SchedulesDeadlines(t *testing.T) {
+	players := []string{"p1", "p2", "p3", "p4", "p5"}
+	st := &fakeGameStore{players: players, config: timerConfig}
+	engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	engine.SetClock(func() time.Time { return now })
+	ctx := context.Background()
+
+	result := engine.ApplyMove(ctx, "g1", "p1", "action", map[string]interface{}{"action": "start_game"})
+	if result.Error != nil {
+		t.Fatalf("start_game: %v", result.Error)
+	}
+	if result.State.Deadline != "2025-03-01T12:01:00Z" {
+		t.Errorf("expected proposal deadline 60s after start, got %q", result.State.Deadline)
+	}
+
+	now = now.Add(10 * time.Second)
+	result = engine.ApplyMove(ctx, "g1", "p1", "action", map[string]interface{}{"action": "propose_team", "team_ids": []interface{}{"p1", "p2"}})
+	if result.Error != nil {
+		t.Fatalf("propose_team: %v", result.Error)
+	}
+	if result.State.Deadline != "2025-03-01T12:00:40Z" {
+		t.Errorf("expected vote deadline 30s after the proposal, got %q", result.State.Deadline)
+	}
+
+	now = now.Add(10 * time.Second)
+	result = engine.ApplyMove(ctx, "g1", "p1", "vote", map[string]interface{}{"approved": true})
+	if result.Error != nil {
+		t.Fatalf("vote: %v", result.Error)
+	}
+	if result.State.Deadline != "2025-03-01T12:00:40Z" {
+		t.Errorf("expected a vote in the same phase to keep the deadline, got %q", result.State.Deadline)
+	}
+
+	if r := engine.ApplyTimeout(ctx, "g1"); r.Error != nil || r.State != nil {
+		t.Errorf("expected no timeout before the deadline, got %+v", r)
+	}
+}
+
+func TestApplyTimeout_Defaults(t *testing.T) {
+	players := []string{"p1", "p2", "p3", "p4", "p5"}
+	roles := map[string]string{"p1": RoleMerlin, "p2": RoleLoyalServant, "p3": RoleLoyalServant, "p4": RoleAssassin, "p5": RoleMinion}
+	past := "2025-03-01T12:00:00Z"
+	tests := []struct {
+		name      string
+		state     *GameState
+		defaulted []string
+		check     func(t *testing.T, next *GameState)
+	}{
+		{
+			name:      "proposal passes the leader's turn",
+			state:     &GameState{Phase: PhaseTeamSelection, LeaderIndex: 4},
+			defaulted: []string{"p5"},
+			check: func(t *testing.T, next *GameState) {
+				if next.Phase != PhaseTeamSelection || next.LeaderIndex != 0 || next.RejectCount != 0 {
+					t.Errorf("expected p1 to lead without a rejection, got %s leader %d rejects %d", next.Phase, next.LeaderIndex, next.RejectCount)
+				}
+			},
+		},
+		{
+			name: "missing team votes approve",
+			state: &GameState{Phase: PhaseTeamVote, ProposedTeam: []string{"p1", "p2"},
+				TeamVotes: map[string]string{"p1": "reject", "p3": "reject"}},
+			defaulted: []string{"p2", "p4", "p5"},
+			check: func(t *testing.T, next *GameState) {
+				if next.Phase != PhaseMissionVote {
+					t.Errorf("expected 3 approvals to send the team, got %s", next.Phase)
+				}
+			},
+		},
+		{
+			name: "missing mission cards succeed",
+			state: &GameState{Phase: PhaseMissionVote, ProposedTeam: []string{"p1", "p2"},
+				MissionVotes: map[string]string{"p1": "fail"}},
+			defaulted: []string{"p2"},
+			check: func(t *testing.T, next *GameState) {
+				if !reflect.DeepEqual(next.MissionResults, []string{"fail"}) {
+					t.Errorf("expected the player's fail card to count, got %v", next.MissionResults)
+				}
+			},
+		},
+		{
+			name:      "lady of the lake is skipped",
+			state:     &GameState{Phase: PhaseLadyOfTheLake, LadyHolder: "p5", LadyHolders: []string{"p5"}},
+			defaulted: []string{"p5"},
+			check: func(t *testing.T, next *GameState) {
+				if next.Phase != PhaseTeamSelection || next.LadyHolder != "p5" {
+					t.Errorf("expected team_selection with p5 keeping the token, got %s/%s", next.Phase, next.LadyHolder)
+				}
+			},
+		},
+		{
+			name:      "assassin runs out of time",
+			state:     &GameState{Phase: PhaseAssassination, MissionResults: []string{"success", "success", "success"}},
+			defaulted: []string{"p4"},
+			check: func(t *testing.T, next *GameState) {
+				if next.Status != "finished" || next.Winner != "good" {
+					t.Errorf("expected good to win, got %s/%s", next.Status, next.Winner)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := tt.state
+			state.GameID, state.Status, state.RoundIndex = "g1", "in_progress", 1
+			state.PlayerIDs, state.Roles, state.Deadline = players, roles, past
+			st := &fakeGameStore{snapshot: snapshotOf(t, state), players: players}
+			engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+			engine.SetClock(func() time.Time { return time.Date(2025, 3, 1, 12, 0, 1, 0, time.UTC) })
+
+			result := engine.ApplyTimeout(context.Background(), "g1")
+			if result.Error != nil {
+				t.Fatalf("ApplyTimeout: %v", result.Error)
+			}
+			if result.State == nil || len(result.Events) < 2 || result.Events[0].Event != "timer_expired" {
+				t.Fatalf("expected timer_expired and the default move's events, got %+v", result)
+			}
+			if got := result.Events[0].Payload["defaulted"]; !reflect.DeepEqual(got, tt.defaulted) {
+				t.Errorf("expected defaulted %v, got %v", tt.defaulted, got)
+			}
+			if len(st.events) != 1 || st.events[0].Type != MoveTypeTimeout || st.events[0].RoomPlayerID != nil {
+				t.Errorf("expected one timeout event without a player, got %+v", st.events)
+			}
+			if result.State.Deadline != "" {
+				t.Errorf("expected no deadline without timers, got %q", result.State.Deadline)
+			}
+			tt.check(t, result.State)
+		})
+	}
+}
+
+func TestReplay_Timeouts(t *testing.T) {
+	players := []string{"p1", "p2", "p3", "p4", "p5"}
+	st := &fakeGameStore{snapshot: map[string]interface{}{"phase": "lobby", "version": float64(1)}, players: players, config: timerConfig}
+	engine := NewEngine(st, &fakeEventStore{}, ClassicAvalonConfig())
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	engine.SetClock(func() time.Time { return now })
+	ctx := context.Background()
+
+	if r := engine.ApplyMove(ctx, "g1", "p1", "action", map[string]interface{}{"action": "start_game"}); r.Error != nil {
+		t.Fatalf("start_game: %v", r.Error)
+	}
+	now = now.Add(time.Minute)
+	if r := engine.ApplyTimeout(ctx, "g1"); r.Error != nil || r.State == nil {
+		t.Fatalf("proposal timeout: %+v", r)
+	}
+	if r := engine.ApplyMove(ctx, "g1", "p2", "action", map[string]interface{}{"action": "propose_team", "team_ids": []interface{}{"p1", "p2"}}); r.Error != nil {
+		t.Fatalf("propose_team: %v", r.Error)
+	}
+	now = now.Add(time.Minute)
+	if r := engine.ApplyTimeout(ctx, "g1"); r.Error != nil || r.State == nil {
+		t.Fatalf("vote timeout: %+v", r)
+	}
+
+	replayEngine := NewEngine(st, &fakeEventStore{events: st.loggedEvents(t)}, ClassicAvalonConfig())
+	replayed, diff, err := replayEngine.VerifySnapshot(ctx, "g1")
+	if err != nil {
+		t.Fatalf("VerifySnapshot: %v", err)
+	}
+	if len(diff) != 0 {
+		t.Errorf("expected replay to match the snapshot, differs in %v", diff)
+	}
+	if replayed.Phase != PhaseMissionVote || replayed.LeaderIndex != 1 {
+		t.Errorf("expected mission_vote under p2, got %s leader %d", replayed.Phase, replayed.LeaderIndex)
+	}
+}
@@ -0,0 +1,84 @@
+package games
+
+// PlayerView returns the state as viewerID may see it, for sync_state and state broadcasts.
+// The roles map is removed while the game is in progress; the viewer gets their own role and alignment,
+// the players their role knows about (see RoleKnowledge) and their Lady of the Lake results.
+// Ballots are secret: team votes appear only as a count (plus the viewer's own vote) until the
+// team_vote_revealed event, and mission cards only as a count.
+// An empty or unknown viewerID gets the public view. All roles and the deal seed are revealed once the game is finished.
+func PlayerView(state *GameState, viewerID string) map[string]interface{} {
+	if state == nil {
+		return map[string]interface{}{}
+	}
+	m := state.ToMap()
+	delete(m, "team_votes")
+	delete(m, "mission_votes")
+	if state.Phase == PhaseTeamVote {
+		m["team_votes_cast"] = len(state.TeamVotes)
+		if v, ok := state.TeamVotes[viewerID]; ok {
+			m["my_team_vote"] = v
+		}
+	}
+	if state.Phase == PhaseMissionVote {
+		m["mission_votes_cast"] = len(state.MissionVotes)
+	}
+	if state.Status == "finished" {
+		return m
+	}
+	delete(m, "roles")
+	delete(m, "seed")
+
+	role, ok := state.Roles[viewerID]
+	if !ok {
+		return m
+	}
+	m["my_role"] = role
+	m["my_alignment"] = AlignmentOf(role)
+	m["known_players"] = RoleKnowledge(state.Roles, viewerID)
+	if results := ladyResults(state, viewerID); len(results) > 0 {
+		m["lady_results"] = results
+	}
+	return m
+}
+
+// SpectatorView returns the state as a spectator may see it: the public view (see PlayerView), which
+// keeps the roles and deal seed hidden even after the game is finished unless reveal is set.
+func SpectatorView(state *GameState, reveal bool) map[string]interface{} {
+	m := PlayerView(state, "")
+	if !reveal {
+		delete(m, "roles")
+		delete(m, "seed")
+	}
+	return m
+}
+
+// RoleBriefing returns viewerID's private night-phase briefing: their role, alignment and the players
+// their role knows about. Returns nil if viewerID has no role in this game.
+func RoleBriefing(state *GameState, viewerID string) map[string]interface{} {
+	if state == nil {
+		return nil
+	}
+	role, ok := state.Roles[viewerID]
+	if !ok {
+		return nil
+	}
+	return map[string]interface{}{
+		"player_id":     viewerID,
+		"role":          role,
+		"alignment":     AlignmentOf(role),
+		"known_players": RoleKnowledge(state.Roles, viewerID),
+	}
+}
+
+// ladyResults returns target room_player_id -> alignment for every inspection viewerID made.
+// Each holder inspected the next holder in LadyHolders.
+func ladyResults(state *GameState, viewerID string) map[string]string {
+	out := make(map[string]string)
+	for i := 0; i+1 < len(state.LadyHolders); i++ {
+		if state.LadyHolders[i] == viewerID {
+			target := state.LadyHolders[i+1]
+			out[target] = AlignmentOf(state.Roles[target])
+		}
+	}
+	return out
+}
@@ -0,0 +1,124 @@
+package games
+
+import "testing"
+
+func TestPlayerView(t *testing.T) {
+	state := &GameState{
+		GameID: "g1", Phase: PhaseTeamSelection, Status: "in_progress",
+		PlayerIDs: []string{"p1", "p2", "p3", "p4", "p5"}, RoundIndex: 3,
+		Roles:       map[string]string{"p1": RoleMerlin, "p2": RoleLoyalServant, "p3": RoleLoyalServant, "p4": RoleAssassin, "p5": RoleMinion},
+		LadyHolders: []string{"p5", "p2"}, LadyHolder: "p2",
+	}
+
+	public := PlayerView(state, "")
+	if _, ok := public["roles"]; ok {
+		t.Error("public view must not include roles")
+	}
+	if _, ok := public["my_role"]; ok {
+		t.Error("public view must not include my_role")
+	}
+
+	merlin := PlayerView(state, "p1")
+	if _, ok := merlin["roles"]; ok {
+		t.Error("player view must not include roles")
+	}
+	if merlin["my_role"] != RoleMerlin || merlin["my_alignment"] != AlignmentGood {
+		t.Errorf("expected merlin/good, got %v/%v", merlin["my_role"], merlin["my_alignment"])
+	}
+	known, _ := merlin["known_players"].(map[string]string)
+	if len(known) != 2 || known["p4"] != KnownAsEvil || known["p5"] != KnownAsEvil {
+		t.Errorf("expected merlin to see p4 and p5 as evil, got %v", known)
+	}
+
+	servant := PlayerView(state, "p3")
+	if known, _ := servant["known_players"].(map[string]string); len(known) != 0 {
+		t.Errorf("loyal servant should know nobody, got %v", known)
+	}
+
+	inspector := PlayerView(state, "p5")
+	if results, _ := inspector["lady_results"].(map[string]string); results["p2"] != AlignmentGood {
+		t.Errorf("expected p5 to see p2's inspection result, got %v", inspector["lady_results"])
+	}
+	if _, ok := servant["lady_results"]; ok {
+		t.Error("only the inspector should see lady_results")
+	}
+
+	state.Seed, state.SeedHash = 42, SeedHash(42)
+	if _, ok := PlayerView(state, "p1")["seed"]; ok {
+		t.Error("seed must stay secret while the game is in progress")
+	}
+	if PlayerView(state, "")["seed_hash"] != SeedHash(42) {
+		t.Error("expected the seed hash to be public")
+	}
+
+	state.Status = "finished"
+	if PlayerView(state, "")["seed"] != "42" {
+		t.Errorf("expected the seed revealed when finished, got %v", PlayerView(state, "")["seed"])
+	}
+	if roles, _ := PlayerView(state, "")["roles"].(map[string]string); len(roles) != 5 {
+		t.Errorf("expected all roles revealed when finished, got %v", roles)
+	}
+}
+
+func TestPlayerView_SecretBallots(t *testing.T) {
+	state := &GameState{
+		GameID: "g1", Phase: PhaseTeamVote, Status: "in_progress",
+		PlayerIDs: []string{"p1", "p2", "p3", "p4", "p5"}, ProposedTeam: []string{"p1", "p2"},
+		TeamVotes: map[string]string{"p1": "approve", "p2": "reject"},
+	}
+	view := PlayerView(state, "p2")
+	if _, ok := view["team_votes"]; ok {
+		t.Error("team votes must stay hidden until revealed")
+	}
+	if view["team_votes_cast"] != 2 || view["my_team_vote"] != "reject" {
+		t.Errorf("expected 2 votes cast and own vote reject, got %v/%v", view["team_votes_cast"], view["my_team_vote"])
+	}
+
+	state.Phase = PhaseMissionVote
+	state.TeamVotes = nil
+	state.MissionVotes = map[string]string{"p1": "fail"}
+	view = PlayerView(state, "p1")
+	if _, ok := view["mission_votes"]; ok {
+		t.Error("mission cards must never be tied to a player")
+	}
+	if view["mission_votes_cast"] != 1 {
+		t.Errorf("expected 1 mission vote cast, got %v", view["mission_votes_cast"])
+	}
+}
+
+func TestRoleBriefing(t *testing.T) {
+	state := &GameState{
+		PlayerIDs: []string{"p1", "p2", "p3", "p4", "p5"},
+		Roles:     map[string]string{"p1": RoleMerlin, "p2": RoleLoyalServant, "p3": RoleLoyalServant, "p4": RoleAssassin, "p5": RoleMinion},
+	}
+	b := RoleBriefing(state, "p4")
+	if b["role"] != RoleAssassin || b["alignment"] != AlignmentEvil {
+		t.Errorf("expected assassin/evil, got %v/%v", b["role"], b["alignment"])
+	}
+	if known, _ := b["known_players"].(map[string]string); len(known) != 1 || known["p5"] != KnownAsEvil {
+		t.Errorf("expected assassin to see only p5, got %v", b["known_players"])
+	}
+	if RoleBriefing(state, "spectator") != nil {
+		t.Error("expected nil briefing for a non-player")
+	}
+}
+
+func TestSpectatorView(t *testing.T) {
+	state := &GameState{
+		GameID: "g1", Phase: PhaseTeamVote, Status: "in_progress", PlayerIDs: []string{"p1", "p2"},
+		Roles:     map[string]string{"p1": RoleMerlin, "p2": RoleAssassin},
+		TeamVotes: map[string]string{"p1": "approve"}, Seed: 42,
+	}
+	view := SpectatorView(state, true)
+	if _, ok := view["roles"]; ok || view["team_votes_cast"] != 1 || view["my_role"] != nil {
+		t.Errorf("expected the public view while in progress, got %v", view)
+	}
+
+	state.Phase, state.Status = PhaseFinished, "finished"
+	if view := SpectatorView(state, false); view["roles"] != nil || view["seed"] != nil {
+		t.Errorf("expected roles and seed hidden without reveal, got %v", view)
+	}
+	if view := SpectatorView(state, true); view["roles"] == nil || view["seed"] != "42" {
+		t.Errorf("expected roles and seed revealed, got %v", view)
+	}
+}
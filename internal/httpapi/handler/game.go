@@ -7,15 +7,24 @@ import (
 	"strings"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/vntrieu/avalon/internal/games"
 	"github.com/vntrieu/avalon/internal/store"
 )
 
 // StartGameRequest is the body for POST /api/rooms/{code}/games.
 // Requires user token; room player is resolved from the authenticated user.
+// Config is parsed by games.ParseConfig (preset plus overrides) and stored normalized with the game.
 type StartGameRequest struct {
 	Config map[string]interface{} `json:"config,omitempty"`
 }
 
+// ConfigErrorResponse is the 400 body when the game config is invalid. Code is always INVALID_CONFIG.
+type ConfigErrorResponse struct {
+	Error  string             `json:"error"`
+	Code   string             `json:"code"`
+	Fields []games.FieldError `json:"fields"`
+}
+
 // GameHandler handles game-related HTTP requests.
 type GameHandler struct {
 	gameStore   *store.GameStore
@@ -38,7 +47,7 @@ func NewGameHandler(gameStore *store.GameStore, roomStore *store.RoomStore, toke
 // @Param        code  path      string               true   "Room code (6 alphanumeric)"
 // @Param        body  body      StartGameRequest     false  "Request body (config optional)"
 // @Success      201   {object}  store.CreateGameResponse
-// @Failure      400   {string}  string  "Bad request or room has no players"
+// @Failure      400   {object}  ConfigErrorResponse  "Invalid config (JSON field errors); other bad requests are plain text"
 // @Failure      401   {string}  string  "Unauthorized (user token required)"
 // @Failure      403   {string}  string  "Only host can start a new game, or user not in room"
 // @Failure      404   {string}  string  "Room not found"
@@ -90,7 +99,13 @@ func (h *GameHandler) CreateGame(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	req := store.CreateGameRequest{Code: code, Config: body.Config}
+	rules, err := games.ParseConfig(body.Config)
+	if err != nil {
+		writeConfigError(w, err)
+		return
+	}
+
+	req := store.CreateGameRequest{Code: code, Config: rules.ToMap()}
 	resp, err := h.gameStore.CreateGame(r.Context(), req)
 	if err != nil {
 		log.Printf("[%s] create game error: %v", requestID(r), err)
@@ -113,3 +128,15 @@ func (h *GameHandler) CreateGame(w http.ResponseWriter, r *http.Request) {
 		log.Printf("[%s] encode response error: %v", requestID(r), err)
 	}
 }
+
+// writeConfigError responds 400 with the field-level errors from games.ParseConfig as JSON.
+func writeConfigError(w http.ResponseWriter, err error) {
+	cfgErr, ok := err.(*games.ConfigError)
+	if !ok {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(ConfigErrorResponse{Error: "invalid config", Code: games.CodeInvalidConfig, Fields: cfgErr.Fields})
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/go-chi/chi/v5"
 
 	"github.com/vntrieu/avalon/internal/auth"
+	"github.com/vntrieu/avalon/internal/games"
 	"github.com/vntrieu/avalon/internal/store"
 )
 
@@ -133,7 +134,7 @@ func (h *RoomHandler) CreateRoom(w http.ResponseWriter, r *http.Request) {
 // JoinRoom handles POST /api/rooms/{code}/join
 //
 // @Summary      Join room
-// @Description  Join an existing room. Requires user token; display_name is taken from user profile.
+// @Description  Join an existing room. Requires user token; display_name is taken from user profile. A user who is already a player in the room gets their room player back.
 // @Tags         rooms
 // @Accept       json
 // @Produce      json
@@ -232,6 +233,96 @@ func (h *RoomHandler) JoinRoom(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// SpectateRoom handles POST /api/rooms/{code}/spectate
+//
+// @Summary      Spectate room
+// @Description  Watch a room without joining it as a player. Requires user token; display_name is taken from user profile. The spectator token gives read-only access to the room WebSocket.
+// @Tags         rooms
+// @Accept       json
+// @Produce      json
+// @Param        code  path      string                    true   "Room code (6 alphanumeric)"
+// @Param        body  body      store.JoinRoomRequest     true   "Request body (password optional)"
+// @Success      200   {object}  store.SpectateRoomResponse
+// @Failure      400   {string}  string  "Bad request"
+// @Failure      401   {string}  string  "Unauthorized or password required/invalid"
+// @Failure      404   {string}  string  "Room not found"
+// @Failure      409   {string}  string  "Already a player in this room"
+// @Failure      500   {string}  string  "Server error"
+// @Security     BearerAuth
+// @Router       /api/rooms/{code}/spectate [post]
+func (h *RoomHandler) SpectateRoom(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := UserIDFromRequest(r)
+	if userID == nil || *userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	user, err := h.userStore.GetUserByID(r.Context(), *userID)
+	if err != nil || user == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if msg := validateDisplayName(user.DisplayName); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	displayName := strings.TrimSpace(user.DisplayName)
+
+	code := chi.URLParam(r, "code")
+	if !validateRoomCode(code) {
+		http.Error(w, "invalid room code format", http.StatusBadRequest)
+		return
+	}
+
+	var req store.JoinRoomRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Code = code
+	if msg := validatePasswordLength(req.Password); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.roomStore.SpectateRoom(r.Context(), req, displayName, *userID)
+	if err != nil {
+		switch errMsg := err.Error(); errMsg {
+		case "room not found":
+			http.Error(w, errMsg, http.StatusNotFound)
+		case "password is required", "invalid password":
+			http.Error(w, errMsg, http.StatusUnauthorized)
+		case "already a player in this room":
+			http.Error(w, errMsg, http.StatusConflict)
+		default:
+			log.Printf("[%s] spectate room error: %v", requestID(r), err)
+			http.Error(w, "failed to spectate room", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if len(h.tokenSecret) > 0 {
+		token, expiresAt, err := auth.GenerateSpectatorToken(resp.Room.ID, resp.SpectatorID, resp.DisplayName, h.tokenSecret, auth.DefaultTokenExpiry)
+		if err != nil {
+			log.Printf("[%s] generate token error: %v", requestID(r), err)
+			http.Error(w, "failed to spectate room", http.StatusInternalServerError)
+			return
+		}
+		resp.Token = token
+		resp.ExpiresAt = &expiresAt
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("[%s] encode response error: %v", requestID(r), err)
+	}
+}
+
 // GetRoom handles GET /api/rooms/{code}
 //
 // @Summary      Get room
@@ -270,6 +361,10 @@ func (h *RoomHandler) GetRoom(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "failed to get room", http.StatusInternalServerError)
 		return
 	}
+	// Unauthenticated: only the public view of a started game's snapshot (no hidden roles).
+	if _, started := resp.LatestGameStateSnapshot["player_ids"]; started {
+		resp.LatestGameStateSnapshot = games.PlayerView(games.StateFromMap(resp.LatestGameStateSnapshot), "")
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
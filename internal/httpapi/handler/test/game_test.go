@@ -170,6 +170,50 @@ func TestCreateGameHandler(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("400 with field errors when config is invalid", func(t *testing.T) {
+		gameHandler, roomHandler, _, hostUser, pool := setupTestGameHandler(t)
+		defer pool.Close()
+
+		createBody, _ := json.Marshal(map[string]interface{}{})
+		createReq := httptest.NewRequest(http.MethodPost, "/api/rooms", bytes.NewReader(createBody))
+		createReq.Header.Set("Content-Type", "application/json")
+		createReq = requestWithUserIDGame(createReq, hostUser.ID)
+		createW := httptest.NewRecorder()
+		roomHandler.CreateRoom(createW, createReq)
+		if createW.Code != http.StatusCreated {
+			t.Fatalf("create room: expected 201, got %d", createW.Code)
+		}
+		var createResp store.CreateRoomResponse
+		if err := json.NewDecoder(createW.Body).Decode(&createResp); err != nil {
+			t.Fatalf("decode create response: %v", err)
+		}
+		code := createResp.Room.Code
+
+		body, _ := json.Marshal(map[string]interface{}{
+			"config": map[string]interface{}{"roles": []string{"jester"}, "max_rejections": 0},
+		})
+		req := httptest.NewRequest(http.MethodPost, "/api/rooms/"+code+"/games", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req = requestWithCodeChi(req, code)
+		req = requestWithUserIDGame(req, hostUser.ID)
+		w := httptest.NewRecorder()
+		gameHandler.CreateGame(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected status 400, got %d body=%s", w.Code, w.Body.String())
+		}
+		var resp handler.ConfigErrorResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		if len(resp.Fields) != 2 {
+			t.Errorf("expected 2 field errors, got %v", resp.Fields)
+		}
+		if resp.Code != "INVALID_CONFIG" {
+			t.Errorf("expected code INVALID_CONFIG, got %q", resp.Code)
+		}
+	})
 }
 
 // TestRoomAndGameLifecycle_Integration runs full flow: create room → get room → join → get room → host creates game → get room returns new game.
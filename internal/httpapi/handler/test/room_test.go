@@ -446,6 +446,52 @@ func TestJoinRoomHandler(t *testing.T) {
 	})
 }
 
+func TestSpectateRoomHandler(t *testing.T) {
+	h, userStore, hostUser, pool := setupTestHandler(t)
+	defer pool.Close()
+	watcher, err := userStore.CreateUser(context.Background(), "watcher@example.com", "password123", "Watcher")
+	if err != nil {
+		t.Fatalf("create watcher: %v", err)
+	}
+	createBody, _ := json.Marshal(map[string]interface{}{"settings": map[string]interface{}{store.SettingSpectatorReveal: true}})
+	createReq := httptest.NewRequest(http.MethodPost, "/api/rooms", bytes.NewReader(createBody))
+	createReq = requestWithUserID(createReq, hostUser.ID)
+	createW := httptest.NewRecorder()
+	h.CreateRoom(createW, createReq)
+	var createResp store.CreateRoomResponse
+	json.NewDecoder(createW.Body).Decode(&createResp)
+	code := createResp.Room.Code
+
+	spectate := func(userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/rooms/"+code+"/spectate", bytes.NewReader([]byte("{}")))
+		req = chiCtxWithCode(code)(req)
+		req = requestWithUserID(req, userID)
+		w := httptest.NewRecorder()
+		h.SpectateRoom(w, req)
+		return w
+	}
+
+	w := spectate(watcher.ID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", w.Code, w.Body.String())
+	}
+	var resp store.SpectateRoomResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.SpectatorID != watcher.ID || resp.DisplayName != "Watcher" || resp.Room.Code != code {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	player, _ := store.NewRoomStore(pool).GetRoomPlayerByUserInRoom(context.Background(), code, watcher.ID)
+	if player != nil {
+		t.Error("expected the spectator not to become a room player")
+	}
+
+	if w := spectate(hostUser.ID); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a player of the room, got %d", w.Code)
+	}
+}
+
 func TestGetRoomHandler(t *testing.T) {
 	t.Run("success returns room and latest game", func(t *testing.T) {
 		h, _, hostUser, pool := setupTestHandler(t)
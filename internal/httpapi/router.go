@@ -1,6 +1,7 @@
 package httpapi
 
 import (
+	"context"
 	"net/http"
 	"time"
 
@@ -20,6 +21,7 @@ import (
 // NewRouter builds the root HTTP router with basic middleware and health check.
 // tokenSecret is used to sign WebSocket auth tokens; if nil or empty, create/join responses omit the token.
 // rateLimiter is optional: if nil, no rate limiting is applied; otherwise create room, join room, and WS chat are limited.
+// hubBackend is optional: if nil, WebSocket broadcasts only reach clients connected to this instance.
 //
 // @title            Avalon API
 // @version          1.0
@@ -28,7 +30,7 @@ import (
 // @SecurityDefinitions.apikey  BearerAuth
 // @in               header
 // @name             Authorization
-func NewRouter(pool *pgxpool.Pool, tokenSecret []byte, rateLimiter ratelimit.Limiter) http.Handler {
+func NewRouter(pool *pgxpool.Pool, tokenSecret []byte, rateLimiter ratelimit.Limiter, hubBackend websocket.Backend) http.Handler {
 	if rateLimiter == nil {
 		rateLimiter = &ratelimit.Noop{}
 	}
@@ -67,7 +69,12 @@ func NewRouter(pool *pgxpool.Pool, tokenSecret []byte, rateLimiter ratelimit.Lim
 	hub := websocket.NewHub(eventHandler)
 	eventHandler = websocket.NewEventHandler(hub, pool, gameStore, engine, rateLimiter)
 	hub.SetEventHandler(eventHandler)
+	if hubBackend != nil {
+		hub.SetBackend(context.Background(), hubBackend)
+	}
 	go hub.Run()
+	// Phase timers: apply default moves when a game's deadline passes (including deadlines missed while down).
+	go eventHandler.RunTimers(context.Background(), websocket.DefaultTimerInterval)
 
 	wsHandler := websocket.NewWSHandler(hub, pool, tokenSecret)
 
@@ -96,6 +103,7 @@ func NewRouter(pool *pgxpool.Pool, tokenSecret []byte, rateLimiter ratelimit.Lim
 		r.With(rateLimitByIP, RequireUser(tokenSecret)).Post("/", roomHandler.CreateRoom)
 		r.Get("/{code}", roomHandler.GetRoom)
 		r.With(rateLimitByIP, RequireUser(tokenSecret)).Post("/{code}/join", roomHandler.JoinRoom)
+		r.With(rateLimitByIP, RequireUser(tokenSecret)).Post("/{code}/spectate", roomHandler.SpectateRoom)
 
 		// Game routes (create game requires user token; room player resolved from user)
 		gameHandler := handler.NewGameHandler(gameStore, roomStore, tokenSecret)
@@ -114,9 +122,10 @@ func DefaultRateLimiter() ratelimit.Limiter {
 	return ratelimit.NewInMemory(20, time.Minute)
 }
 
-// SetupRoomWSRouter returns a chi router with only GET /ws/rooms/{code} for testing.
+// SetupRoomWSRouter returns a chi router with only the WebSocket routes (room and game) for testing.
 func SetupRoomWSRouter(wsHandler *websocket.WSHandler) http.Handler {
 	r := chi.NewRouter()
 	r.Get("/ws/rooms/{code}", wsHandler.HandleRoomWebSocket)
+	r.Get("/api/rooms/{code}/games/{game_id}/ws", wsHandler.HandleWebSocket)
 	return r
 }
@@ -2,6 +2,7 @@ package httpapi_test
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -57,6 +58,54 @@ func TestRoomWebSocket_Unauthorized(t *testing.T) {
 	}
 }
 
+// TestGameWebSocket_Auth verifies that the game WS takes the room token and only serves the room's own games.
+func TestGameWebSocket_Auth(t *testing.T) {
+	router, code, token, pool := setupRoomWSWithEngine(t)
+	defer pool.Close()
+	ctx := context.Background()
+	roomStore := store.NewRoomStore(pool)
+	gameStore := store.NewGameStore(pool)
+	room, err := roomStore.GetRoom(ctx, code)
+	if err != nil {
+		t.Fatalf("get room: %v", err)
+	}
+	game, err := gameStore.CreateGame(ctx, store.CreateGameRequest{RoomID: room.Room.ID})
+	if err != nil {
+		t.Fatalf("create game: %v", err)
+	}
+	other, err := roomStore.CreateRoom(ctx, store.CreateRoomRequest{}, "OtherHost", nil)
+	if err != nil {
+		t.Fatalf("create other room: %v", err)
+	}
+	otherGame, err := gameStore.CreateGame(ctx, store.CreateGameRequest{RoomID: other.Room.ID})
+	if err != nil {
+		t.Fatalf("create other game: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name, path string
+		want       int
+	}{
+		{"no token", "/api/rooms/" + code + "/games/" + game.Game.ID + "/ws", http.StatusUnauthorized},
+		{"unverified room_player_id", "/api/rooms/" + code + "/games/" + game.Game.ID + "/ws?room_player_id=" + room.Room.ID, http.StatusUnauthorized},
+		{"game of another room", "/api/rooms/" + code + "/games/" + otherGame.Game.ID + "/ws?token=" + token, http.StatusNotFound},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, w.Code)
+		}
+	}
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+	conn, _, err := wsgorilla.DefaultDialer.Dial(serverWSURL(server, "/api/rooms/"+code+"/games/"+game.Game.ID+"/ws?token="+token), nil)
+	if err != nil {
+		t.Fatalf("dial with token: %v", err)
+	}
+	conn.Close()
+}
+
 // setupRoomWSWithEngine returns router, room code, and a token for the host (room_id, room_player_id from createResp).
 func setupRoomWSWithEngine(t *testing.T) (http.Handler, string, string, *pgxpool.Pool) {
 	t.Helper()
@@ -113,14 +162,7 @@ func TestRoomWebSocket_ValidToken_SyncState(t *testing.T) {
 	}
 
 	// Read state envelope (type "state", event "state")
-	var envelope struct {
-		Type    string                 `json:"type"`
-		Event   string                 `json:"event"`
-		Payload map[string]interface{} `json:"payload"`
-	}
-	if err := conn.ReadJSON(&envelope); err != nil {
-		t.Fatalf("read: %v", err)
-	}
+	envelope := readEnvelope(t, conn)
 	if envelope.Type != "state" {
 		t.Errorf("expected envelope type state, got %s", envelope.Type)
 	}
@@ -176,15 +218,7 @@ func TestRoomWebSocket_ChatBroadcast(t *testing.T) {
 	}
 
 	// Player2 should receive chat event (broadcast to room except sender)
-	var envelope struct {
-		Type    string                 `json:"type"`
-		Event   string                 `json:"event"`
-		Payload map[string]interface{} `json:"payload"`
-	}
-	conn2.SetReadDeadline(time.Now().Add(2 * time.Second))
-	if err := conn2.ReadJSON(&envelope); err != nil {
-		t.Fatalf("read envelope: %v", err)
-	}
+	envelope := readEnvelope(t, conn2)
 	if envelope.Type != "event" {
 		t.Errorf("expected type event, got %s", envelope.Type)
 	}
@@ -210,11 +244,7 @@ func TestRoomWebSocket_ReconnectSyncState(t *testing.T) {
 		t.Fatalf("dial 1: %v", err)
 	}
 	conn1.WriteJSON(map[string]string{"type": "sync_state"})
-	var firstEnvelope struct {
-		Type    string                 `json:"type"`
-		Payload map[string]interface{} `json:"payload"`
-	}
-	conn1.ReadJSON(&firstEnvelope)
+	readEnvelope(t, conn1)
 	conn1.Close()
 
 	time.Sleep(20 * time.Millisecond)
@@ -227,14 +257,7 @@ func TestRoomWebSocket_ReconnectSyncState(t *testing.T) {
 	defer conn2.Close()
 
 	conn2.WriteJSON(map[string]string{"type": "sync_state"})
-	var secondEnvelope struct {
-		Type    string                 `json:"type"`
-		Payload map[string]interface{} `json:"payload"`
-	}
-	conn2.SetReadDeadline(time.Now().Add(2 * time.Second))
-	if err := conn2.ReadJSON(&secondEnvelope); err != nil {
-		t.Fatalf("read after reconnect: %v", err)
-	}
+	secondEnvelope := readEnvelope(t, conn2)
 	if secondEnvelope.Type != "state" {
 		t.Errorf("expected type state after reconnect, got %s", secondEnvelope.Type)
 	}
@@ -243,3 +266,29 @@ func TestRoomWebSocket_ReconnectSyncState(t *testing.T) {
 		t.Error("expected payload with phase or state after sync_state")
 	}
 }
+
+// readEnvelope reads conn until an envelope other than the session and presence notices every socket receives
+// unprompted. The server may send several envelopes in one frame.
+func readEnvelope(t *testing.T, conn *wsgorilla.Conn) websocket.ServerEnvelope {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, r, err := conn.NextReader()
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		dec := json.NewDecoder(r)
+		for {
+			var envelope websocket.ServerEnvelope
+			if err := dec.Decode(&envelope); err != nil {
+				break
+			}
+			switch envelope.Event {
+			case websocket.ServerEventSession, websocket.ServerEventPlayerJoined, websocket.ServerEventPlayerOnline,
+				websocket.ServerEventPlayerOffline, websocket.ServerEventPlayerLeft:
+				continue
+			}
+			return envelope
+		}
+	}
+}
@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -17,7 +18,7 @@ import (
 type Game struct {
 	ID        string                 `json:"id"`
 	RoomID    string                 `json:"room_id"`
-	Status    string                 `json:"status"` // waiting | in_progress | finished
+	Status    string                 `json:"status"` // waiting | in_progress | paused | finished
 	Config    map[string]interface{} `json:"config"`
 	CreatedAt time.Time              `json:"created_at"`
 	EndedAt   *time.Time             `json:"ended_at,omitempty"`
@@ -51,6 +52,34 @@ type CreateGameResponse struct {
 // LobbyStateJSON is the initial snapshot state for a new game (phase: lobby).
 var LobbyStateJSON = []byte(`{"phase":"lobby"}`)
 
+// ErrStaleState is returned by CommitMove when the game's latest snapshot is no longer the version the move was computed from.
+var ErrStaleState = errors.New("stale state")
+
+// MoveCommit is everything one game move writes. CommitMove applies it atomically.
+type MoveCommit struct {
+	// ExpectedVersion is the latest snapshot version the move was computed from (0 if the game had none).
+	ExpectedVersion int32
+	// Event is appended to game_events when set.
+	Event *CreateGameEventRequest
+	// State is written as snapshot ExpectedVersion+1.
+	State map[string]interface{}
+	// Roles, when set, are written to game_players.role (room_player_id -> role).
+	Roles map[string]string
+	// Status, when set, updates games.status (and ended_at from EndedAt).
+	Status  string
+	EndedAt *time.Time
+	// Seats records seats handed to another room player: the old player's game_players row gets left_at,
+	// the new player's row is created (or rejoined) with the seat's role.
+	Seats []SeatChange
+}
+
+// SeatChange moves a game seat (and its role) from one room player to another.
+type SeatChange struct {
+	From string
+	To   string
+	Role string
+}
+
 // GameStore handles database operations for games.
 type GameStore struct {
 	pool    *pgxpool.Pool
@@ -240,29 +269,71 @@ func (s *GameStore) GetLatestGameForRoom(ctx context.Context, roomID string) (*G
 	return dbGameToStoreGame(&games[0]), nil
 }
 
-// CreateOrUpdateSnapshot creates a new snapshot for the game with the next version number.
-// stateJSON is the full state to store. Returns the new snapshot's version.
-func (s *GameStore) CreateOrUpdateSnapshot(ctx context.Context, gameID string, stateJSON map[string]interface{}) (version int32, err error) {
+// ListGamesPastDeadline returns the in-progress games whose latest snapshot has a phase deadline at or before now.
+func (s *GameStore) ListGamesPastDeadline(ctx context.Context, now time.Time) ([]*Game, error) {
+	rows, err := s.queries.ListGamesPastDeadline(ctx, pgtype.Timestamptz{Time: now, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("list games past deadline: %w", err)
+	}
+	out := make([]*Game, 0, len(rows))
+	for i := range rows {
+		out = append(out, dbGameToStoreGame(&rows[i]))
+	}
+	return out, nil
+}
+
+// CommitMove writes a move in one transaction: it locks the game row, checks that the latest snapshot is still
+// commit.ExpectedVersion, then appends the event, writes the next snapshot, roles and status together.
+// Returns the new snapshot version, or ErrStaleState if another move committed first.
+func (s *GameStore) CommitMove(ctx context.Context, gameID string, commit MoveCommit) (int32, error) {
 	gameUUID, err := stringToUUID(gameID)
 	if err != nil {
 		return 0, fmt.Errorf("invalid game_id: %w", err)
 	}
 	data := []byte("{}")
-	if len(stateJSON) > 0 {
-		data, err = json.Marshal(stateJSON)
+	if len(commit.State) > 0 {
+		data, err = json.Marshal(commit.State)
 		if err != nil {
 			return 0, fmt.Errorf("marshal state: %w", err)
 		}
 	}
-	var nextVersion int32 = 1
-	snapshot, err := s.queries.GetLatestGameStateSnapshotByGameId(ctx, gameUUID)
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	txQueries := s.queries.WithTx(tx)
+
+	// Moves on the same game queue here until the holder commits.
+	if _, err := txQueries.LockGameForUpdate(ctx, gameUUID); err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, fmt.Errorf("game not found")
+		}
+		return 0, fmt.Errorf("lock game: %w", err)
+	}
+	var current int32
+	snapshot, err := txQueries.GetLatestGameStateSnapshotByGameId(ctx, gameUUID)
 	if err != nil && err != pgx.ErrNoRows {
 		return 0, fmt.Errorf("get latest snapshot: %w", err)
 	}
 	if err == nil {
-		nextVersion = snapshot.Version + 1
+		current = snapshot.Version
+	}
+	if current != commit.ExpectedVersion {
+		return 0, ErrStaleState
+	}
+
+	if commit.Event != nil {
+		params, err := createGameEventParams(*commit.Event)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := txQueries.CreateGameEvent(ctx, params); err != nil {
+			return 0, fmt.Errorf("create game event: %w", err)
+		}
 	}
-	_, err = s.queries.CreateGameStateSnapshot(ctx, db.CreateGameStateSnapshotParams{
+	nextVersion := current + 1
+	_, err = txQueries.CreateGameStateSnapshot(ctx, db.CreateGameStateSnapshotParams{
 		GameID:    gameUUID,
 		Version:   nextVersion,
 		StateJson: data,
@@ -270,10 +341,72 @@ func (s *GameStore) CreateOrUpdateSnapshot(ctx context.Context, gameID string, s
 	if err != nil {
 		return 0, fmt.Errorf("create snapshot: %w", err)
 	}
+	for _, seat := range commit.Seats {
+		if err := commitSeatChange(ctx, txQueries, gameUUID, seat); err != nil {
+			return 0, err
+		}
+	}
+	for roomPlayerID, role := range commit.Roles {
+		playerUUID, err := stringToUUID(roomPlayerID)
+		if err != nil {
+			return 0, fmt.Errorf("invalid room_player_id: %w", err)
+		}
+		err = txQueries.UpdateGamePlayerRole(ctx, db.UpdateGamePlayerRoleParams{
+			GameID:       gameUUID,
+			RoomPlayerID: playerUUID,
+			Role:         pgtype.Text{String: role, Valid: true},
+		})
+		if err != nil {
+			return 0, fmt.Errorf("update game player role: %w", err)
+		}
+	}
+	if commit.Status != "" {
+		var endAt pgtype.Timestamptz
+		if commit.EndedAt != nil {
+			endAt = pgtype.Timestamptz{Time: *commit.EndedAt, Valid: true}
+		}
+		err = txQueries.UpdateGameStatus(ctx, db.UpdateGameStatusParams{ID: gameUUID, Status: commit.Status, EndedAt: endAt})
+		if err != nil {
+			return 0, fmt.Errorf("update game status: %w", err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
 	return nextVersion, nil
 }
 
+// commitSeatChange writes one SeatChange within CommitMove's transaction.
+func commitSeatChange(ctx context.Context, q *db.Queries, gameUUID pgtype.UUID, seat SeatChange) error {
+	fromUUID, err := stringToUUID(seat.From)
+	if err != nil {
+		return fmt.Errorf("invalid room_player_id: %w", err)
+	}
+	toUUID, err := stringToUUID(seat.To)
+	if err != nil {
+		return fmt.Errorf("invalid room_player_id: %w", err)
+	}
+	err = q.SetGamePlayerLeftAt(ctx, db.SetGamePlayerLeftAtParams{
+		GameID:       gameUUID,
+		RoomPlayerID: fromUUID,
+		LeftAt:       pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("set game player left_at: %w", err)
+	}
+	err = q.UpsertGamePlayer(ctx, db.UpsertGamePlayerParams{
+		GameID:       gameUUID,
+		RoomPlayerID: toUUID,
+		Role:         pgtype.Text{String: seat.Role, Valid: seat.Role != ""},
+	})
+	if err != nil {
+		return fmt.Errorf("upsert game player: %w", err)
+	}
+	return nil
+}
+
 // GetLatestSnapshot returns the latest game state snapshot as a map, or nil if none exists.
+// The map's "version" is the snapshot row's version.
 func (s *GameStore) GetLatestSnapshot(ctx context.Context, gameID string) (map[string]interface{}, error) {
 	gameUUID, err := stringToUUID(gameID)
 	if err != nil {
@@ -295,24 +428,35 @@ func (s *GameStore) GetLatestSnapshot(ctx context.Context, gameID string) (map[s
 	if out == nil {
 		out = make(map[string]interface{})
 	}
+	out["version"] = float64(snapshot.Version)
 	return out, nil
 }
 
-// UpdateGameStatus updates the game's status and optionally ended_at.
-func (s *GameStore) UpdateGameStatus(ctx context.Context, gameID string, status string, endedAt *time.Time) error {
+// GetSnapshotAtVersion returns the game's snapshot with the given version as a map (like GetLatestSnapshot),
+// or nil if there is none.
+func (s *GameStore) GetSnapshotAtVersion(ctx context.Context, gameID string, version int32) (map[string]interface{}, error) {
 	gameUUID, err := stringToUUID(gameID)
 	if err != nil {
-		return fmt.Errorf("invalid game_id: %w", err)
+		return nil, fmt.Errorf("invalid game_id: %w", err)
+	}
+	snapshot, err := s.queries.GetGameStateSnapshotByVersion(ctx, db.GetGameStateSnapshotByVersionParams{GameID: gameUUID, Version: version})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get snapshot at version: %w", err)
 	}
-	var endAt pgtype.Timestamptz
-	if endedAt != nil {
-		endAt = pgtype.Timestamptz{Time: *endedAt, Valid: true}
+	var out map[string]interface{}
+	if len(snapshot.StateJson) > 0 {
+		if err := json.Unmarshal(snapshot.StateJson, &out); err != nil {
+			return nil, fmt.Errorf("unmarshal snapshot: %w", err)
+		}
 	}
-	return s.queries.UpdateGameStatus(ctx, db.UpdateGameStatusParams{
-		ID:      gameUUID,
-		Status:  status,
-		EndedAt: endAt,
-	})
+	if out == nil {
+		out = make(map[string]interface{})
+	}
+	out["version"] = float64(snapshot.Version)
+	return out, nil
 }
 
 // GetGamePlayerIDsInOrder returns room_player_id list for the game in display order (by room join order).
@@ -331,3 +475,123 @@ func (s *GameStore) GetGamePlayerIDsInOrder(ctx context.Context, gameID string)
 	}
 	return ids, nil
 }
+
+// GetGameHostID returns the room_player_id of the room host among the game's players ("" if none).
+func (s *GameStore) GetGameHostID(ctx context.Context, gameID string) (string, error) {
+	gameUUID, err := stringToUUID(gameID)
+	if err != nil {
+		return "", fmt.Errorf("invalid game_id: %w", err)
+	}
+	players, err := s.queries.GetRoomPlayersByGameId(ctx, gameUUID)
+	if err != nil {
+		return "", fmt.Errorf("get game players: %w", err)
+	}
+	for _, p := range players {
+		if p.IsHost {
+			return uuidToString(p.ID), nil
+		}
+	}
+	return "", nil
+}
+
+// IsGameRoomMember reports whether roomPlayerID belongs to the room the game is played in.
+func (s *GameStore) IsGameRoomMember(ctx context.Context, gameID string, roomPlayerID string) (bool, error) {
+	gameUUID, err := stringToUUID(gameID)
+	if err != nil {
+		return false, fmt.Errorf("invalid game_id: %w", err)
+	}
+	game, err := s.queries.GetGameById(ctx, gameUUID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, fmt.Errorf("game not found")
+		}
+		return false, fmt.Errorf("get game: %w", err)
+	}
+	players, err := s.queries.GetRoomPlayersByRoomId(ctx, game.RoomID)
+	if err != nil {
+		return false, fmt.Errorf("get room players: %w", err)
+	}
+	for _, p := range players {
+		if uuidToString(p.ID) == roomPlayerID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ErrDisplayNameTaken is returned by SeatSpectator when a room player already has the spectator's display name.
+var ErrDisplayNameTaken = errors.New("display name already taken in this room")
+
+// SeatSpectator returns the room_player_id a spectator (userID, the spectator token's subject) takes a seat under:
+// their room player in the game's room, created with their profile display name if they have none yet.
+// Returns "" if userID is not a user.
+func (s *GameStore) SeatSpectator(ctx context.Context, gameID string, userID string) (string, error) {
+	gameUUID, err := stringToUUID(gameID)
+	if err != nil {
+		return "", fmt.Errorf("invalid game_id: %w", err)
+	}
+	userUUID, err := stringToUUID(userID)
+	if err != nil {
+		return "", nil
+	}
+	game, err := s.queries.GetGameById(ctx, gameUUID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return "", fmt.Errorf("game not found")
+		}
+		return "", fmt.Errorf("get game: %w", err)
+	}
+	user, err := s.queries.GetUserByID(ctx, userUUID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("get user: %w", err)
+	}
+	existing, err := s.queries.GetRoomPlayerByRoomIdAndUserId(ctx, db.GetRoomPlayerByRoomIdAndUserIdParams{
+		RoomID: game.RoomID,
+		UserID: userUUID,
+	})
+	if err == nil {
+		return uuidToString(existing.ID), nil
+	}
+	if err != pgx.ErrNoRows {
+		return "", fmt.Errorf("get room player by user: %w", err)
+	}
+	taken, err := s.queries.CheckDisplayNameExists(ctx, db.CheckDisplayNameExistsParams{
+		RoomID:      game.RoomID,
+		DisplayName: user.DisplayName,
+	})
+	if err != nil {
+		return "", fmt.Errorf("check display name exists: %w", err)
+	}
+	if taken {
+		return "", ErrDisplayNameTaken
+	}
+	roomPlayer, err := s.queries.CreateRoomPlayer(ctx, db.CreateRoomPlayerParams{
+		RoomID:      game.RoomID,
+		DisplayName: user.DisplayName,
+		IsHost:      false,
+		UserID:      userUUID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("insert room player: %w", err)
+	}
+	return uuidToString(roomPlayer.ID), nil
+}
+
+// GetGameConfig returns the game's config_json as a map (empty map when unset).
+func (s *GameStore) GetGameConfig(ctx context.Context, gameID string) (map[string]interface{}, error) {
+	gameUUID, err := stringToUUID(gameID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid game_id: %w", err)
+	}
+	gameRow, err := s.queries.GetGameById(ctx, gameUUID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("game not found")
+		}
+		return nil, fmt.Errorf("get game: %w", err)
+	}
+	return dbGameToStoreGame(&gameRow).Config, nil
+}
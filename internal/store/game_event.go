@@ -45,38 +45,9 @@ func NewGameEventStore(queries *db.Queries) *GameEventStore {
 
 // CreateGameEvent creates a new game event.
 func (s *GameEventStore) CreateGameEvent(ctx context.Context, req CreateGameEventRequest) (*GameEvent, error) {
-	// Convert game_id to UUID
-	gameUUID, err := stringToUUID(req.GameID)
+	createParams, err := createGameEventParams(req)
 	if err != nil {
-		return nil, fmt.Errorf("invalid game_id: %w", err)
-	}
-
-	// Convert room_player_id to UUID if provided
-	var roomPlayerUUID pgtype.UUID
-	if req.RoomPlayerID != nil && *req.RoomPlayerID != "" {
-		uuid, err := stringToUUID(*req.RoomPlayerID)
-		if err != nil {
-			return nil, fmt.Errorf("invalid room_player_id: %w", err)
-		}
-		roomPlayerUUID = uuid
-	}
-
-	// Serialize payload to JSONB
-	payloadJSON := []byte("{}")
-	if req.Payload != nil && len(req.Payload) > 0 {
-		var err error
-		payloadJSON, err = json.Marshal(req.Payload)
-		if err != nil {
-			return nil, fmt.Errorf("marshal payload: %w", err)
-		}
-	}
-
-	// Create event
-	createParams := db.CreateGameEventParams{
-		GameID:       gameUUID,
-		RoomPlayerID: roomPlayerUUID,
-		Type:         req.Type,
-		PayloadJson:  payloadJSON,
+		return nil, err
 	}
 
 	eventRow, err := s.queries.CreateGameEvent(ctx, createParams)
@@ -146,3 +117,39 @@ func (s *GameEventStore) GetGameEvents(ctx context.Context, gameID string) ([]Ga
 
 	return events, nil
 }
+
+// createGameEventParams converts a CreateGameEventRequest to db params (UUIDs and JSONB payload).
+func createGameEventParams(req CreateGameEventRequest) (db.CreateGameEventParams, error) {
+	// Convert game_id to UUID
+	gameUUID, err := stringToUUID(req.GameID)
+	if err != nil {
+		return db.CreateGameEventParams{}, fmt.Errorf("invalid game_id: %w", err)
+	}
+
+	// Convert room_player_id to UUID if provided
+	var roomPlayerUUID pgtype.UUID
+	if req.RoomPlayerID != nil && *req.RoomPlayerID != "" {
+		uuid, err := stringToUUID(*req.RoomPlayerID)
+		if err != nil {
+			return db.CreateGameEventParams{}, fmt.Errorf("invalid room_player_id: %w", err)
+		}
+		roomPlayerUUID = uuid
+	}
+
+	// Serialize payload to JSONB
+	payloadJSON := []byte("{}")
+	if req.Payload != nil && len(req.Payload) > 0 {
+		var err error
+		payloadJSON, err = json.Marshal(req.Payload)
+		if err != nil {
+			return db.CreateGameEventParams{}, fmt.Errorf("marshal payload: %w", err)
+		}
+	}
+
+	return db.CreateGameEventParams{
+		GameID:       gameUUID,
+		RoomPlayerID: roomPlayerUUID,
+		Type:         req.Type,
+		PayloadJson:  payloadJSON,
+	}, nil
+}
@@ -2,9 +2,12 @@ package store
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
+
 	"github.com/vntrieu/avalon/internal/db"
 )
 
@@ -430,3 +433,98 @@ func TestCreateGame_EdgeCases(t *testing.T) {
 		}
 	})
 }
+
+func TestCommitMove(t *testing.T) {
+	pool := SetupTestDB(t)
+	defer pool.Close()
+
+	roomStore := NewRoomStore(pool)
+	gameStore := NewGameStore(pool)
+	ctx := context.Background()
+
+	roomResp, err := roomStore.CreateRoom(ctx, CreateRoomRequest{}, "HostPlayer", nil)
+	if err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+	gameResp, err := gameStore.CreateGame(ctx, CreateGameRequest{RoomID: roomResp.Room.ID})
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	gameID := gameResp.Game.ID
+	playerID := roomResp.RoomPlayer.ID
+
+	commit := MoveCommit{
+		ExpectedVersion: 1,
+		Event:           &CreateGameEventRequest{GameID: gameID, RoomPlayerID: &playerID, Type: "action", Payload: map[string]interface{}{"action": "start_game"}},
+		State:           map[string]interface{}{"phase": "team_selection"},
+		Roles:           map[string]string{playerID: "merlin"},
+		Status:          "in_progress",
+	}
+
+	t.Run("commits event, snapshot, roles and status together", func(t *testing.T) {
+		version, err := gameStore.CommitMove(ctx, gameID, commit)
+		if err != nil {
+			t.Fatalf("CommitMove failed: %v", err)
+		}
+		if version != 2 {
+			t.Errorf("expected version 2, got %d", version)
+		}
+		snapshot, err := gameStore.GetLatestSnapshot(ctx, gameID)
+		if err != nil {
+			t.Fatalf("GetLatestSnapshot failed: %v", err)
+		}
+		if snapshot["phase"] != "team_selection" || snapshot["version"] != float64(2) {
+			t.Errorf("expected team_selection at version 2, got %v", snapshot)
+		}
+		events, err := NewGameEventStore(db.New(pool)).GetGameEvents(ctx, gameID)
+		if err != nil {
+			t.Fatalf("GetGameEvents failed: %v", err)
+		}
+		if len(events) != 1 {
+			t.Errorf("expected 1 event, got %d", len(events))
+		}
+		game, err := db.New(pool).GetGameById(ctx, mustUUID(t, gameID))
+		if err != nil {
+			t.Fatalf("GetGameById failed: %v", err)
+		}
+		if game.Status != "in_progress" {
+			t.Errorf("expected status in_progress, got %s", game.Status)
+		}
+	})
+
+	t.Run("stale expected version writes nothing", func(t *testing.T) {
+		_, err := gameStore.CommitMove(ctx, gameID, commit)
+		if !errors.Is(err, ErrStaleState) {
+			t.Fatalf("expected ErrStaleState, got %v", err)
+		}
+		events, err := NewGameEventStore(db.New(pool)).GetGameEvents(ctx, gameID)
+		if err != nil {
+			t.Fatalf("GetGameEvents failed: %v", err)
+		}
+		if len(events) != 1 {
+			t.Errorf("expected the stale move's event to be rolled back, got %d events", len(events))
+		}
+	})
+
+	t.Run("earlier snapshots stay readable by version", func(t *testing.T) {
+		snapshot, err := gameStore.GetSnapshotAtVersion(ctx, gameID, 1)
+		if err != nil {
+			t.Fatalf("GetSnapshotAtVersion failed: %v", err)
+		}
+		if snapshot == nil || snapshot["version"] != float64(1) || snapshot["phase"] == "team_selection" {
+			t.Errorf("expected the initial snapshot at version 1, got %v", snapshot)
+		}
+		if snapshot, err := gameStore.GetSnapshotAtVersion(ctx, gameID, 9); err != nil || snapshot != nil {
+			t.Errorf("expected no snapshot at version 9, got %v / %v", snapshot, err)
+		}
+	})
+}
+
+func mustUUID(t *testing.T, s string) pgtype.UUID {
+	t.Helper()
+	u, err := stringToUUID(s)
+	if err != nil {
+		t.Fatalf("invalid uuid %q: %v", s, err)
+	}
+	return u
+}
@@ -72,6 +72,32 @@ type JoinRoomResponse struct {
 	ExpiresAt               *time.Time             `json:"expires_at,omitempty"`
 }
 
+// SpectateRoomResponse contains the response after joining a room as a spectator. A spectator watches the room
+// without becoming a room player, so they are never seated in a game.
+// Token and ExpiresAt are set by the HTTP handler after calling SpectateRoom.
+type SpectateRoomResponse struct {
+	Room        *Room      `json:"room"`
+	SpectatorID string     `json:"spectator_id"`
+	DisplayName string     `json:"display_name"`
+	LatestGame  *Game      `json:"latest_game,omitempty"`
+	Token       string     `json:"token,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// SettingSpectatorReveal is the room setting (bool, set by the host) that shows spectators every role
+// once a game is finished. Without it spectators only ever see the public state.
+const SettingSpectatorReveal = "spectator_reveal"
+
+// SpectatorReveal reports whether the room settings JSON turns on SettingSpectatorReveal.
+func SpectatorReveal(settingsJSON []byte) bool {
+	var settings map[string]interface{}
+	if err := json.Unmarshal(settingsJSON, &settings); err != nil {
+		return false
+	}
+	reveal, _ := settings[SettingSpectatorReveal].(bool)
+	return reveal
+}
+
 // GetRoomResponse contains room info, latest game descriptor, and latest snapshot for GET /api/rooms/{code}.
 type GetRoomResponse struct {
 	Room                    *Room                  `json:"room"`
@@ -323,6 +349,7 @@ func (s *RoomStore) CreateRoom(ctx context.Context, req CreateRoomRequest, displ
 
 // JoinRoom allows a player to join an existing room by code.
 // displayName is the joining player's display name (from the authenticated user). userID must be non-nil (required).
+// A user who is already a player in the room (e.g. a spectator the host seated) gets their room player back.
 func (s *RoomStore) JoinRoom(ctx context.Context, req JoinRoomRequest, displayName string, userID *string) (*JoinRoomResponse, error) {
 	if displayName == "" {
 		return nil, fmt.Errorf("display_name is required")
@@ -340,14 +367,8 @@ func (s *RoomStore) JoinRoom(ctx context.Context, req JoinRoomRequest, displayNa
 	roomID := uuidToString(roomRow.ID)
 
 	// Validate password if room has one
-	passwordHash := textToString(roomRow.PasswordHash)
-	if passwordHash != nil {
-		if req.Password == "" {
-			return nil, fmt.Errorf("password is required")
-		}
-		if err := bcrypt.CompareHashAndPassword([]byte(*passwordHash), []byte(req.Password)); err != nil {
-			return nil, fmt.Errorf("invalid password")
-		}
+	if err := checkRoomPassword(roomRow.PasswordHash, req.Password); err != nil {
+		return nil, err
 	}
 
 	// Check if display name already exists in room
@@ -356,6 +377,11 @@ func (s *RoomStore) JoinRoom(ctx context.Context, req JoinRoomRequest, displayNa
 		return nil, fmt.Errorf("convert room id to uuid: %w", err)
 	}
 
+	// A user who already has a room player here (e.g. a spectator the host seated) gets that player back.
+	if rejoin, err := s.rejoinRoom(ctx, roomRow, req.Code, userID); rejoin != nil || err != nil {
+		return rejoin, err
+	}
+
 	checkParams := db.CheckDisplayNameExistsParams{
 		RoomID:      roomUUID,
 		DisplayName: displayName,
@@ -454,6 +480,127 @@ func (s *RoomStore) JoinRoom(ctx context.Context, req JoinRoomRequest, displayNa
 	}, nil
 }
 
+// rejoinRoom returns the JoinRoomResponse for userID's existing room player in roomRow, or nil if they have none.
+func (s *RoomStore) rejoinRoom(ctx context.Context, roomRow db.GetRoomByCodeRow, code string, userID *string) (*JoinRoomResponse, error) {
+	if userID == nil || *userID == "" {
+		return nil, nil
+	}
+	userUUID, err := stringToUUID(*userID)
+	if err != nil {
+		return nil, nil
+	}
+	row, err := s.queries.GetRoomPlayerByRoomIdAndUserId(ctx, db.GetRoomPlayerByRoomIdAndUserIdParams{
+		RoomID: roomRow.ID,
+		UserID: userUUID,
+	})
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get room player by user: %w", err)
+	}
+	var settings map[string]interface{}
+	if err := json.Unmarshal(roomRow.SettingsJson, &settings); err != nil {
+		settings = make(map[string]interface{})
+	}
+	resp := &JoinRoomResponse{
+		Room: &Room{
+			ID:        uuidToString(roomRow.ID),
+			Code:      code,
+			Settings:  settings,
+			CreatedAt: timestamptzToTime(roomRow.CreatedAt),
+			UpdatedAt: timestamptzToTime(roomRow.UpdatedAt),
+		},
+		RoomPlayer: &RoomPlayer{
+			ID:          uuidToString(row.ID),
+			RoomID:      uuidToString(row.RoomID),
+			DisplayName: row.DisplayName,
+			IsHost:      row.IsHost,
+			UserID:      userID,
+			CreatedAt:   timestamptzToTime(row.CreatedAt),
+		},
+	}
+	games, err := s.queries.GetGamesByRoomId(ctx, roomRow.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get games by room: %w", err)
+	}
+	if len(games) > 0 {
+		resp.LatestGame = dbGameToStoreGame(&games[0])
+	}
+	return resp, nil
+}
+
+// SpectateRoom lets a user watch the room identified by req.Code without joining it as a player.
+// The spectator's ID is their user ID. Users who are already players in the room are refused.
+func (s *RoomStore) SpectateRoom(ctx context.Context, req JoinRoomRequest, displayName string, userID string) (*SpectateRoomResponse, error) {
+	if displayName == "" {
+		return nil, fmt.Errorf("display_name is required")
+	}
+	roomRow, err := s.queries.GetRoomByCode(ctx, req.Code)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("room not found")
+		}
+		return nil, fmt.Errorf("get room by code: %w", err)
+	}
+	if err := checkRoomPassword(roomRow.PasswordHash, req.Password); err != nil {
+		return nil, err
+	}
+	userUUID, err := stringToUUID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user_id: %w", err)
+	}
+	_, err = s.queries.GetRoomPlayerByRoomIdAndUserId(ctx, db.GetRoomPlayerByRoomIdAndUserIdParams{
+		RoomID: roomRow.ID,
+		UserID: userUUID,
+	})
+	if err == nil {
+		return nil, fmt.Errorf("already a player in this room")
+	}
+	if err != pgx.ErrNoRows {
+		return nil, fmt.Errorf("get room player by user: %w", err)
+	}
+
+	var settings map[string]interface{}
+	if err := json.Unmarshal(roomRow.SettingsJson, &settings); err != nil {
+		settings = make(map[string]interface{})
+	}
+	resp := &SpectateRoomResponse{
+		Room: &Room{
+			ID:        uuidToString(roomRow.ID),
+			Code:      req.Code,
+			Settings:  settings,
+			CreatedAt: timestamptzToTime(roomRow.CreatedAt),
+			UpdatedAt: timestamptzToTime(roomRow.UpdatedAt),
+		},
+		SpectatorID: userID,
+		DisplayName: displayName,
+	}
+	games, err := s.queries.GetGamesByRoomId(ctx, roomRow.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get games by room: %w", err)
+	}
+	if len(games) > 0 {
+		resp.LatestGame = dbGameToStoreGame(&games[0])
+	}
+	return resp, nil
+}
+
+// checkRoomPassword returns an error if the room has a password and password does not match it.
+func checkRoomPassword(passwordHash pgtype.Text, password string) error {
+	hash := textToString(passwordHash)
+	if hash == nil {
+		return nil
+	}
+	if password == "" {
+		return fmt.Errorf("password is required")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(*hash), []byte(password)); err != nil {
+		return fmt.Errorf("invalid password")
+	}
+	return nil
+}
+
 // GetRoomPlayerInRoom returns the room player with the given ID if they belong to the room identified by code.
 // Returns (nil, error) if room not found or player not in room.
 func (s *RoomStore) GetRoomPlayerInRoom(ctx context.Context, code string, roomPlayerID string) (*RoomPlayer, error) {
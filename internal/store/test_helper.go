@@ -42,6 +42,8 @@ func SetupTestDB(t *testing.T) *pgxpool.Pool {
 func cleanupTestData(ctx context.Context, pool *pgxpool.Pool) error {
 	// Delete in reverse order of foreign key dependencies
 	tables := []string{
+		"ws_replies",
+		"hub_messages",
 		"chat_messages",
 		"game_events",
 		"game_state_snapshots",
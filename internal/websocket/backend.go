@@ -0,0 +1,31 @@
+package websocket
+
+import (
+	"context"
+
+	"github.com/vntrieu/avalon/internal/store"
+)
+
+// Backend carries hub broadcasts between server instances, so that clients of one room connected to different
+// instances all receive them. Each hub delivers to its own clients directly; the backend only reaches the others.
+// Without a backend (the default) broadcasts stay on this instance. See PGNotifyBackend.
+type Backend interface {
+	// Publish hands msg to the other instances. It must not block the caller for long.
+	Publish(msg *RemoteBroadcast)
+	// Run passes the messages published by other instances to deliver until ctx is done.
+	Run(ctx context.Context, deliver func(*RemoteBroadcast))
+}
+
+// RemoteBroadcast is a hub broadcast as it travels between instances. A ViewFunc cannot travel, so a state
+// broadcast carries the game state itself and each instance builds its own clients' views (see Hub.BroadcastState).
+// The excluded sender of a broadcast is always local, so other instances deliver to every client.
+// Presence carries an instance's connection counts instead of a broadcast (see PresenceCounts).
+type RemoteBroadcast struct {
+	RoomID     string                 `json:"room_id"`
+	Event      *store.GameEvent       `json:"event,omitempty"`
+	Envelope   *ServerEnvelope        `json:"envelope,omitempty"`
+	ToPlayerID string                 `json:"to_player_id,omitempty"`
+	GameID     string                 `json:"game_id,omitempty"`
+	State      map[string]interface{} `json:"state,omitempty"`
+	Presence   *PresenceCounts        `json:"presence,omitempty"`
+}
@@ -5,10 +5,10 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
-	"github.com/vntrieu/avalon/internal/store"
 )
 
 const (
@@ -56,6 +56,24 @@ type Client struct {
 	// DisplayName for chat and broadcasts (room WS)
 	DisplayName string
 
+	// Spectator is set for read-only room WS clients; RoomPlayerID is then the spectator's ID
+	Spectator bool
+
+	// SpectatorReveal lets a spectator see all roles once the game is finished (room setting)
+	SpectatorReveal bool
+
+	// SessionID identifies the client's session for resuming it after a reconnect (see replay.go). Set by the
+	// hub on register; a client resuming a session sends its ID with the last seq it saw.
+	SessionID string
+	resuming  bool
+	lastSeq   int64
+
+	// Outbound policy state (see outbound.go)
+	outMu   sync.Mutex
+	closed  bool             // send is closed
+	evicted bool             // closed for falling behind
+	pending *OutgoingMessage // latest state waiting for room in send
+
 	// RateLimitKey is set at connection time (e.g. client IP) for rate limiting chat/actions.
 	RateLimitKey string
 
@@ -87,19 +105,8 @@ func (c *Client) readPump() {
 		}
 
 		if c.GameID != "" {
-			// Game WS: parse as game event request
-			var eventReq store.CreateGameEventRequest
-			if err := json.Unmarshal(message, &eventReq); err != nil {
-				log.Printf("error unmarshaling game message: %v", err)
-				continue
-			}
-			eventReq.GameID = c.GameID
-			if c.RoomPlayerID != "" {
-				eventReq.RoomPlayerID = &c.RoomPlayerID
-			}
-			if c.hub.eventHandler != nil {
-				c.hub.eventHandler.HandleEvent(c.ctx, c, &eventReq)
-			}
+			// Game WS is read-only: moves and chat go through the room WS and the game engine.
+			sendEnvelopeToClient(c, errorEnvelope(ErrorCodeReadOnly, "game socket is read-only; use the room socket"))
 			continue
 		}
 
@@ -159,6 +166,12 @@ func (c *Client) writePump() {
 					log.Printf("error encoding queued message: %v", err)
 				}
 			}
+			// Then the latest state that did not fit in the buffer
+			if pending := c.takePending(); pending != nil {
+				if err := json.NewEncoder(w).Encode(pending.Envelope); err != nil {
+					log.Printf("error encoding pending state: %v", err)
+				}
+			}
 
 			if err := w.Close(); err != nil {
 				return
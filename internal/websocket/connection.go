@@ -0,0 +1,29 @@
+package websocket
+
+import (
+	"context"
+	"log"
+)
+
+// UpdateConnection records in the room's current game whether roomPlayerID still has an open connection and
+// broadcasts the change (player_disconnected / player_reconnected). The status is read from the hub when the
+// update runs, so notifications handled out of order, or by several instances, still settle on the real status.
+// Connections on every instance count (see Hub.IsPlayerConnected).
+func (h *EventHandler) UpdateConnection(roomID string, roomPlayerID string) {
+	if h.hub == nil || h.gameStore == nil || h.engine == nil {
+		return
+	}
+	ctx := context.Background()
+	game, err := h.gameStore.GetLatestGameForRoom(ctx, roomID)
+	if err != nil || game == nil || (game.Status != "in_progress" && game.Status != "paused") {
+		return
+	}
+	result := h.engine.SetPlayerConnected(ctx, game.ID, roomPlayerID, h.hub.IsPlayerConnected(roomID, roomPlayerID))
+	if result.Error != nil {
+		log.Printf("update connection for game %s player %s: %v", game.ID, roomPlayerID, result.Error)
+		return
+	}
+	if result.State != nil {
+		h.broadcastResult(roomID, game.ID, result)
+	}
+}
@@ -2,133 +2,188 @@ package websocket
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/vntrieu/avalon/internal/db"
+	"github.com/vntrieu/avalon/internal/games"
 	"github.com/vntrieu/avalon/internal/store"
 )
 
-func TestEventHandler_HandleEvent(t *testing.T) {
-	pool := store.SetupTestDB(t)
-	defer pool.Close()
-
-	hub := NewHub(nil)
-	gameStore := store.NewGameStore(pool)
-	eventHandler := NewEventHandler(hub, pool, gameStore, nil, nil)
-	hub.SetEventHandler(eventHandler)
-	go hub.Run()
+func TestMoveErrorEnvelope(t *testing.T) {
+	client := &Client{}
+	moveErr := &games.MoveError{Code: games.CodeNotLeader, Message: "only the leader can propose a team", Details: map[string]interface{}{"leader_id": "p1"}}
+	env := moveErrorEnvelope(client, moveErr)
+	if env.Type != ServerTypeError || env.Payload["code"] != games.CodeNotLeader ||
+		env.Payload["message"] != moveErr.Message || env.Payload["details"] == nil {
+		t.Errorf("expected NOT_LEADER with message and details, got %+v", env)
+	}
+	env = moveErrorEnvelope(client, errors.New("get state: connection refused"))
+	if env.Payload["code"] != games.CodeInternal || env.Payload["message"] != "internal error" {
+		t.Errorf("expected internal error without its text, got %+v", env)
+	}
+}
 
-	// Create room and game
-	roomStore := store.NewRoomStore(pool)
+func TestHandleRoomMessage_CorrelationID(t *testing.T) {
+	h := &EventHandler{replies: newReplyCache(ReplyTTL, nil)}
+	client := &Client{send: make(chan *OutgoingMessage, 4), RoomID: "r1", RoomPlayerID: "p1"}
 	ctx := context.Background()
 
-	createRoomReq := store.CreateRoomRequest{}
-	roomResp, err := roomStore.CreateRoom(ctx, createRoomReq, "TestPlayer", nil)
-	if err != nil {
-		t.Fatalf("failed to create room: %v", err)
+	h.HandleRoomMessage(ctx, client, &ClientInMessage{Type: ClientMessageTypeVote, CorrelationID: "c1"})
+	out := <-client.send
+	if out.Envelope.Type != ServerTypeError || out.Envelope.CorrelationID != "c1" || out.Envelope.Payload["code"] != ErrorCodeUnavailable {
+		t.Errorf("expected an error for c1, got %+v", out.Envelope)
 	}
-
-	createGameReq := store.CreateGameRequest{
-		RoomID: roomResp.Room.ID,
+	h.HandleRoomMessage(ctx, client, &ClientInMessage{Type: "bogus", CorrelationID: "c2"})
+	if out = <-client.send; out.Envelope.CorrelationID != "c2" || out.Envelope.Payload["code"] != ErrorCodeUnsupportedType {
+		t.Errorf("expected unsupported type for c2, got %+v", out.Envelope)
 	}
-	gameResp, err := gameStore.CreateGame(ctx, createGameReq)
-	if err != nil {
-		t.Fatalf("failed to create game: %v", err)
+	h.HandleRoomMessage(ctx, client, &ClientInMessage{Type: ClientMessageTypeVote})
+	if out = <-client.send; out.Envelope.CorrelationID != "" {
+		t.Errorf("expected no correlation_id, got %q", out.Envelope.CorrelationID)
 	}
+}
 
-	// Create a mock client
-	client := &Client{
-		hub:          hub,
-		send:         make(chan *OutgoingMessage, 256),
-		RoomID:       roomResp.Room.ID,
-		GameID:       gameResp.Game.ID,
-		RoomPlayerID: roomResp.RoomPlayer.ID,
-		ctx:          ctx,
+func TestHandleRoomMessage_Spectator(t *testing.T) {
+	h := &EventHandler{replies: newReplyCache(ReplyTTL, nil)}
+	client := &Client{send: make(chan *OutgoingMessage, 4), RoomID: "r1", RoomPlayerID: "s1", Spectator: true}
+	ctx := context.Background()
+
+	for _, msgType := range []string{ClientMessageTypeChat, ClientMessageTypeVote, ClientMessageTypeAction} {
+		h.HandleRoomMessage(ctx, client, &ClientInMessage{Type: msgType, Payload: map[string]interface{}{"message": "hi"}})
+		if out := <-client.send; out.Envelope.Payload["code"] != ErrorCodeSpectator {
+			t.Errorf("%s: expected %s, got %+v", msgType, ErrorCodeSpectator, out.Envelope)
+		}
 	}
+	h.HandleRoomMessage(ctx, client, &ClientInMessage{Type: ClientMessageTypeSyncState})
+	if out := <-client.send; out.Envelope.Payload["code"] != ErrorCodeUnavailable {
+		t.Errorf("expected sync_state to be allowed, got %+v", out.Envelope)
+	}
+}
 
-	// Register client
-	hub.register <- client
+func TestStateEnvelope_Spectator(t *testing.T) {
+	state := &games.GameState{GameID: "g1", Phase: games.PhaseFinished, Status: "finished", PlayerIDs: []string{"p1", "p2"},
+		Roles: map[string]string{"p1": games.RoleMerlin, "p2": games.RoleAssassin}}
+	player := stateEnvelope("g1", state, &Client{RoomPlayerID: "p1"})
+	if view := player.Payload["state"].(map[string]interface{}); view["roles"] == nil {
+		t.Errorf("expected players to see the roles once finished, got %v", view)
+	}
+	spectator := stateEnvelope("g1", state, &Client{RoomPlayerID: "s1", Spectator: true})
+	if view := spectator.Payload["state"].(map[string]interface{}); view["roles"] != nil {
+		t.Errorf("expected spectators not to see the roles, got %v", view)
+	}
+	revealed := stateEnvelope("g1", state, &Client{RoomPlayerID: "s1", Spectator: true, SpectatorReveal: true})
+	if view := revealed.Payload["state"].(map[string]interface{}); view["roles"] == nil {
+		t.Errorf("expected spectator_reveal to show the roles, got %v", view)
+	}
+}
 
-	// Give time for registration
-	time.Sleep(10 * time.Millisecond)
+func TestReplyCache(t *testing.T) {
+	c := newReplyCache(time.Minute, nil)
+	ctx := context.Background()
+	k := replyKey{roomID: "r1", playerID: "p1", correlationID: "k"}
+	if _, first := c.begin(ctx, k); !first {
+		t.Fatal("expected the first message to be handled")
+	}
 
-	// Create event request
-	eventReq := &store.CreateGameEventRequest{
-		GameID: gameResp.Game.ID,
-		Type:   "test_event",
-		Payload: map[string]interface{}{
-			"test": "data",
-		},
+	// A retry while the first attempt runs waits for its reply.
+	got := make(chan *ServerEnvelope)
+	go func() {
+		reply, first := c.begin(ctx, k)
+		if first {
+			t.Error("expected the retry not to be handled again")
+		}
+		got <- reply
+	}()
+	ack := ackEnvelope(ClientMessageTypeVote, map[string]interface{}{"version": 3})
+	c.finish(ctx, k, ack)
+	if reply := <-got; reply != ack {
+		t.Errorf("expected the retry to get the ack, got %+v", reply)
+	}
+	if reply, first := c.begin(ctx, k); first || reply != ack {
+		t.Errorf("expected the ack again, got %+v", reply)
 	}
 
-	// Handle event
-	eventHandler.HandleEvent(ctx, client, eventReq)
+	// Transient failures are not kept.
+	transient := replyKey{roomID: "r1", playerID: "p1", correlationID: "t"}
+	c.begin(ctx, transient)
+	c.finish(ctx, transient, errorEnvelope(games.CodeStaleState, "stale state"))
+	if _, first := c.begin(ctx, transient); !first {
+		t.Error("expected a retry after a stale state error to be handled again")
+	}
+}
 
-	// Give time for processing
-	time.Sleep(50 * time.Millisecond)
+func TestReplyCache_WaitTimeout(t *testing.T) {
+	c := newReplyCache(time.Minute, nil)
+	c.wait = 20 * time.Millisecond
+	k := replyKey{roomID: "r1", playerID: "p1", correlationID: "k"}
+	c.begin(context.Background(), k)
 
-	// Verify event was NOT broadcast back to sender (sender should not receive their own event)
-	select {
-	case out := <-client.send:
-		if out.GameEvent != nil {
-			t.Errorf("sender should not receive their own event, but received: %s", out.GameEvent.Type)
-		}
-	case <-time.After(100 * time.Millisecond):
-		// Expected - sender should not receive their own event
-	}
-
-	// Verify event was created in database
-	queries := db.New(pool)
-	eventStore := store.NewGameEventStore(queries)
-	events, err := eventStore.GetGameEvents(ctx, gameResp.Game.ID)
-	if err != nil {
-		t.Fatalf("failed to get events: %v", err)
-	}
-	if len(events) == 0 {
-		t.Error("event should have been created in database")
-	} else {
-		if events[0].Type != "test_event" {
-			t.Errorf("expected event type 'test_event', got %s", events[0].Type)
-		}
+	// The first attempt never finishes: the retry gives up after c.wait instead of blocking its connection.
+	start := time.Now()
+	if reply, first := c.begin(context.Background(), k); first || reply != nil {
+		t.Errorf("expected no reply, got %+v (first %v)", reply, first)
+	}
+	if waited := time.Since(start); waited > time.Second {
+		t.Errorf("expected the retry to stop waiting after %v, waited %v", c.wait, waited)
 	}
 }
 
-func TestEventHandler_InvalidGameID(t *testing.T) {
+// Without a database each instance only knows its own replies; with one they are shared through ws_replies.
+func TestReplyCache_SharedAcrossInstances(t *testing.T) {
 	pool := store.SetupTestDB(t)
 	defer pool.Close()
 
-	hub := NewHub(nil)
-	gameStore := store.NewGameStore(pool)
-	eventHandler := NewEventHandler(hub, pool, gameStore, nil, nil)
-	hub.SetEventHandler(eventHandler)
-	go hub.Run()
+	a := newReplyCache(time.Minute, db.New(pool))
+	b := newReplyCache(time.Minute, db.New(pool))
+	ctx := context.Background()
+	k := replyKey{roomID: "r1", playerID: "p1", correlationID: "k"}
 
-	client := &Client{
-		hub:          hub,
-		send:         make(chan *OutgoingMessage, 256),
-		RoomID:       "room-1",
-		GameID:       "invalid-game-id",
-		RoomPlayerID: "player-1",
-		ctx:          context.Background(),
+	if _, first := a.begin(ctx, k); !first {
+		t.Fatal("expected the first message to be handled")
 	}
-
-	eventReq := &store.CreateGameEventRequest{
-		GameID: "invalid-game-id",
-		Type:   "test_event",
+	got := make(chan *ServerEnvelope)
+	go func() {
+		reply, first := b.begin(ctx, k)
+		if first {
+			t.Error("expected the retry on the other instance not to be handled again")
+		}
+		got <- reply
+	}()
+	a.finish(ctx, k, ackEnvelope(ClientMessageTypeVote, map[string]interface{}{"version": 3}))
+	if reply := <-got; reply == nil || reply.Type != ServerTypeAck || reply.Payload["version"] != float64(3) {
+		t.Errorf("expected the other instance to get the ack, got %+v", reply)
 	}
 
-	// Should not panic, but event won't be created
-	eventHandler.HandleEvent(context.Background(), client, eventReq)
-
-	// Give time for processing
-	time.Sleep(10 * time.Millisecond)
+	transient := replyKey{roomID: "r1", playerID: "p1", correlationID: "t"}
+	a.begin(ctx, transient)
+	a.finish(ctx, transient, errorEnvelope(games.CodeStaleState, "stale state"))
+	if _, first := b.begin(ctx, transient); !first {
+		t.Error("expected a retry after a stale state error to be handled again")
+	}
+}
 
-	// Client should not receive event (game doesn't exist)
-	select {
-	case <-client.send:
-		t.Error("client should not receive event for invalid game")
-	case <-time.After(50 * time.Millisecond):
-		// Expected - no event should be sent
+func TestSyncVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload map[string]interface{}
+		want    int
+		ok      bool
+	}{
+		{"none", nil, 0, false},
+		{"version", map[string]interface{}{"version": float64(12)}, 12, true},
+		{"same game", map[string]interface{}{"version": float64(12), "game_id": "g1"}, 12, true},
+		{"other game", map[string]interface{}{"version": float64(12), "game_id": "g0"}, 0, false},
+		{"not a whole number", map[string]interface{}{"version": 1.5}, 0, false},
+		{"zero", map[string]interface{}{"version": float64(0)}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := syncVersion(tt.payload, "g1")
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("syncVersion = %d, %v; want %d, %v", got, ok, tt.want, tt.ok)
+			}
+		})
 	}
 }
@@ -16,11 +16,11 @@ import (
 // EventHandler handles game events and broadcasts them.
 type EventHandler struct {
 	hub         *Hub
-	eventStore  *store.GameEventStore
 	gameStore   *store.GameStore
 	engine      *games.Engine
 	queries     *db.Queries
 	rateLimiter ratelimit.Limiter
+	replies     *replyCache
 }
 
 // NewGameEngine creates a game engine with the given game store and pool (for event store).
@@ -34,163 +34,293 @@ func NewGameEngine(gameStore *store.GameStore, pool *pgxpool.Pool) *games.Engine
 // rateLimiter is optional; when set, chat messages are rate-limited by client key (e.g. IP).
 func NewEventHandler(hub *Hub, pool *pgxpool.Pool, gameStore *store.GameStore, engine *games.Engine, rateLimiter ratelimit.Limiter) *EventHandler {
 	queries := db.New(pool)
-	eventStore := store.NewGameEventStore(queries)
 	if engine == nil && gameStore != nil {
-		engine = games.NewEngine(gameStore, eventStore, games.ClassicAvalonConfig())
+		engine = games.NewEngine(gameStore, store.NewGameEventStore(queries), games.ClassicAvalonConfig())
+	}
+	var replyQueries *db.Queries
+	if pool != nil {
+		replyQueries = queries
 	}
 	return &EventHandler{
 		hub:         hub,
-		eventStore:  eventStore,
 		gameStore:   gameStore,
 		engine:      engine,
 		queries:     queries,
 		rateLimiter: rateLimiter,
+		replies:     newReplyCache(ReplyTTL, replyQueries),
 	}
 }
 
 // HandleRoomMessage processes an incoming room message (chat, vote, action, sync_state).
-// Rejects unknown or invalid message types with an error envelope.
+// Rejects unknown or invalid message types with an error envelope. A message with a correlation_id is answered
+// with an ack or error envelope carrying the same correlation_id; chat, vote and action messages resent with a
+// correlation_id that was already handled get the earlier reply again instead of being applied twice.
 func (h *EventHandler) HandleRoomMessage(ctx context.Context, client *Client, msg *ClientInMessage) {
 	if msg == nil {
-		sendErrorToClient(client, "invalid message")
+		sendEnvelopeToClient(client, errorEnvelope(ErrorCodeInvalidMessage, "invalid message"))
+		return
+	}
+	correlationID := msg.CorrelationID
+	if len(correlationID) > MaxCorrelationIDLength {
+		sendEnvelopeToClient(client, errorEnvelope(ErrorCodeInvalidMessage, "correlation_id too long"))
 		return
 	}
 	// Validate type: allowlist and length to prevent abuse
 	if len(msg.Type) > MaxClientMessageTypeLength {
-		sendErrorToClient(client, "invalid message type")
+		h.reply(client, correlationID, errorEnvelope(ErrorCodeInvalidMessage, "invalid message type"))
 		return
 	}
 	if !ValidClientMessageTypes[msg.Type] {
-		sendErrorToClient(client, "unsupported message type")
+		h.reply(client, correlationID, errorEnvelope(ErrorCodeUnsupportedType, "unsupported message type"))
+		return
+	}
+	if client.Spectator && !SpectatorMessageTypes[msg.Type] {
+		h.reply(client, correlationID, errorEnvelope(ErrorCodeSpectator, "spectators cannot send "+msg.Type))
+		return
+	}
+	// sync_state only reads, so a retry simply runs again.
+	if correlationID == "" || msg.Type == ClientMessageTypeSyncState || h.replies == nil {
+		h.reply(client, correlationID, h.dispatch(ctx, client, msg))
+		return
+	}
+	key := replyKeyFor(client, correlationID)
+	if earlier, first := h.replies.begin(ctx, key); !first {
+		if earlier != nil {
+			h.reply(client, correlationID, earlier)
+		}
 		return
 	}
+	reply := h.dispatch(ctx, client, msg)
+	h.replies.finish(ctx, key, reply)
+	h.reply(client, correlationID, reply)
+}
+
+// dispatch handles msg by type and returns the reply: an ack or an error envelope.
+func (h *EventHandler) dispatch(ctx context.Context, client *Client, msg *ClientInMessage) *ServerEnvelope {
 	switch msg.Type {
 	case ClientMessageTypeChat:
-		h.handleChat(ctx, client, msg)
+		return h.handleChat(ctx, client, msg)
 	case ClientMessageTypeVote:
-		h.handleVote(ctx, client, msg)
+		return h.handleVote(ctx, client, msg)
 	case ClientMessageTypeAction:
-		h.handleAction(ctx, client, msg)
+		return h.handleAction(ctx, client, msg)
 	case ClientMessageTypeSyncState:
-		h.handleSyncState(ctx, client, msg)
-	default:
-		sendErrorToClient(client, "unsupported message type")
+		return h.handleSyncState(ctx, client, msg)
+	}
+	return errorEnvelope(ErrorCodeUnsupportedType, "unsupported message type")
+}
+
+// reply sends reply to client with correlationID. Errors are always sent; acks only when the client asked
+// for one with a correlation_id.
+func (h *EventHandler) reply(client *Client, correlationID string, reply *ServerEnvelope) {
+	if reply == nil || (reply.Type == ServerTypeAck && correlationID == "") {
+		return
 	}
+	out := *reply
+	out.CorrelationID = correlationID
+	sendEnvelopeToClient(client, &out)
 }
 
 // handleSyncState loads the latest game snapshot for the client's room and sends a state message to that client only.
-func (h *EventHandler) handleSyncState(ctx context.Context, client *Client, msg *ClientInMessage) {
+// With payload {"version": N} (the last version the client saw) it sends a state_delta with only the changes since,
+// unless the gap is too big (see games.MaxSyncGap). With payload {"scope": "role"} it re-sends the client's private
+// role_assigned briefing instead. State messages carry the room's roster (see Hub.Roster), also before the first game.
+func (h *EventHandler) handleSyncState(ctx context.Context, client *Client, msg *ClientInMessage) *ServerEnvelope {
 	if h.gameStore == nil || h.engine == nil {
-		sendErrorToClient(client, "sync_state not available")
-		return
+		return errorEnvelope(ErrorCodeUnavailable, "sync_state not available")
 	}
+	scope, _ := msg.Payload["scope"].(string)
 	game, err := h.gameStore.GetLatestGameForRoom(ctx, client.RoomID)
-	if err != nil || game == nil {
-		sendErrorToClient(client, "no game found for room")
-		return
+	if err != nil || (game == nil && scope == SyncScopeRole) {
+		return errorEnvelope(ErrorCodeNoGame, "no game found for room")
+	}
+	if game == nil {
+		payload := map[string]interface{}{"state": map[string]interface{}{"phase": "lobby"}}
+		sendEnvelopeToClient(client, h.withRoster(client, &ServerEnvelope{Type: ServerTypeState, Event: ServerEventState, Payload: payload}))
+		return ackEnvelope(msg.Type, nil)
 	}
 	state, err := h.engine.GetState(ctx, game.ID)
 	if err != nil {
-		sendErrorToClient(client, "failed to load state")
-		return
+		return errorEnvelope(games.CodeInternal, "failed to load state")
 	}
-	payload := map[string]interface{}{"game_id": game.ID}
-	if state != nil {
-		payload["state"] = state.ToMap()
-		payload["phase"] = state.Phase
-		payload["version"] = state.Version
-	} else {
-		payload["state"] = map[string]interface{}{"phase": "lobby"}
+	if scope == SyncScopeRole {
+		briefing := games.RoleBriefing(state, client.RoomPlayerID)
+		if briefing == nil {
+			return errorEnvelope(ErrorCodeNoRole, "no role assigned")
+		}
+		sendEnvelopeToClient(client, &ServerEnvelope{Type: ServerTypeEvent, Event: ServerEventRoleAssigned, Payload: briefing})
+		return ackEnvelope(msg.Type, map[string]interface{}{"game_id": game.ID})
+	}
+	if state == nil {
+		payload := map[string]interface{}{"game_id": game.ID, "state": map[string]interface{}{"phase": "lobby"}}
+		sendEnvelopeToClient(client, h.withRoster(client, &ServerEnvelope{Type: ServerTypeState, Event: ServerEventState, Payload: payload}))
+		return ackEnvelope(msg.Type, map[string]interface{}{"game_id": game.ID})
+	}
+	// A player that knows an earlier version of this game gets only what changed since.
+	if since, ok := syncVersion(msg.Payload, game.ID); ok && !client.Spectator {
+		delta, err := h.engine.SyncSince(ctx, game.ID, state, client.RoomPlayerID, since)
+		if err != nil {
+			log.Printf("sync_state delta for game %s: %v", game.ID, err)
+		}
+		if delta != nil {
+			sendEnvelopeToClient(client, h.withRoster(client, deltaEnvelope(game.ID, state, delta)))
+			return ackEnvelope(msg.Type, map[string]interface{}{"game_id": game.ID, "version": state.Version, "delta": true})
+		}
 	}
-	envelope := &ServerEnvelope{Type: ServerTypeState, Event: ServerEventState, Payload: payload}
-	sendEnvelopeToClient(client, envelope)
+	sendEnvelopeToClient(client, h.withRoster(client, stateEnvelope(game.ID, state, client)))
+	return ackEnvelope(msg.Type, map[string]interface{}{"game_id": game.ID, "version": state.Version})
 }
 
-// handleVote parses payload and calls engine ApplyMove with type "vote"; broadcasts result or sends error to client.
-func (h *EventHandler) handleVote(ctx context.Context, client *Client, msg *ClientInMessage) {
-	if h.gameStore == nil || h.engine == nil {
-		sendErrorToClient(client, "vote not available")
-		return
+// Resync sends client the full state, as for sync_state, when the session it asked to resume could not be replayed.
+func (h *EventHandler) Resync(client *Client) {
+	h.reply(client, "", h.handleSyncState(client.ctx, client, &ClientInMessage{Type: ClientMessageTypeSyncState}))
+}
+
+// withRoster adds the roster of client's room to a sync_state reply.
+func (h *EventHandler) withRoster(client *Client, envelope *ServerEnvelope) *ServerEnvelope {
+	if h.hub != nil {
+		envelope.Payload["roster"] = h.hub.Roster(client.RoomID)
 	}
-	game, err := h.gameStore.GetLatestGameForRoom(ctx, client.RoomID)
-	if err != nil || game == nil {
-		sendErrorToClient(client, "no game found for room")
-		return
+	return envelope
+}
+
+// syncVersion returns the sync_state payload "version" if the client sent one for gameID. A payload "game_id"
+// naming another game (e.g. the room's previous game) means the version is of no use.
+func syncVersion(payload map[string]interface{}, gameID string) (int, bool) {
+	if id, ok := payload["game_id"].(string); ok && id != "" && id != gameID {
+		return 0, false
 	}
-	payload := games.DecodePayload(msg.Payload)
-	if payload == nil {
-		payload = make(map[string]interface{})
+	v, ok := payload["version"].(float64)
+	if !ok || v <= 0 || v != float64(int(v)) {
+		return 0, false
 	}
-	result := h.engine.ApplyMove(ctx, game.ID, client.RoomPlayerID, "vote", payload)
-	if result.Error != nil {
-		sendErrorToClient(client, result.Error.Error())
-		return
+	return int(v), true
+}
+
+// deltaEnvelope builds the state_delta message: the changes to the client's view since delta.FromVersion.
+func deltaEnvelope(gameID string, state *games.GameState, delta *games.StateDelta) *ServerEnvelope {
+	return &ServerEnvelope{Type: ServerTypeState, Event: ServerEventStateDelta, Payload: map[string]interface{}{
+		"game_id":      gameID,
+		"phase":        state.Phase,
+		"from_version": delta.FromVersion,
+		"version":      delta.Version,
+		"changed":      delta.Changed,
+		"removed":      delta.Removed,
+	}}
+}
+
+// stateEnvelope builds the state message for client: the game state redacted by games.PlayerView,
+// or by games.SpectatorView for a spectator.
+func stateEnvelope(gameID string, state *games.GameState, client *Client) *ServerEnvelope {
+	view := games.PlayerView(state, client.RoomPlayerID)
+	if client.Spectator {
+		view = games.SpectatorView(state, client.SpectatorReveal)
+	}
+	return &ServerEnvelope{Type: ServerTypeState, Event: ServerEventState, Payload: map[string]interface{}{
+		"game_id": gameID,
+		"state":   view,
+		"phase":   state.Phase,
+		"version": state.Version,
+	}}
+}
+
+// handleVote parses payload and calls engine ApplyMove with type "vote"; broadcasts the result and acks, or returns the error.
+func (h *EventHandler) handleVote(ctx context.Context, client *Client, msg *ClientInMessage) *ServerEnvelope {
+	if h.gameStore == nil || h.engine == nil {
+		return errorEnvelope(ErrorCodeUnavailable, "vote not available")
 	}
-	h.broadcastResult(ctx, client, game.ID, result)
+	return h.handleMove(ctx, client, msg, "vote")
 }
 
 // handleAction parses payload (action type + params) and calls engine ApplyMove with type "action".
-func (h *EventHandler) handleAction(ctx context.Context, client *Client, msg *ClientInMessage) {
+func (h *EventHandler) handleAction(ctx context.Context, client *Client, msg *ClientInMessage) *ServerEnvelope {
 	if h.gameStore == nil || h.engine == nil {
-		sendErrorToClient(client, "action not available")
-		return
+		return errorEnvelope(ErrorCodeUnavailable, "action not available")
 	}
+	return h.handleMove(ctx, client, msg, "action")
+}
+
+// handleMove applies the client's move to the room's latest game and broadcasts the result. The ack carries
+// the game's new version, so the client can tell whether a state message already includes its move.
+func (h *EventHandler) handleMove(ctx context.Context, client *Client, msg *ClientInMessage, moveType string) *ServerEnvelope {
 	game, err := h.gameStore.GetLatestGameForRoom(ctx, client.RoomID)
 	if err != nil || game == nil {
-		sendErrorToClient(client, "no game found for room")
-		return
+		return errorEnvelope(ErrorCodeNoGame, "no game found for room")
 	}
 	payload := games.DecodePayload(msg.Payload)
 	if payload == nil {
 		payload = make(map[string]interface{})
 	}
-	result := h.engine.ApplyMove(ctx, game.ID, client.RoomPlayerID, "action", payload)
+	result := h.engine.ApplyMove(ctx, game.ID, client.RoomPlayerID, moveType, payload)
 	if result.Error != nil {
-		sendErrorToClient(client, result.Error.Error())
-		return
+		return moveErrorEnvelope(client, result.Error)
+	}
+	h.broadcastResult(client.RoomID, game.ID, result)
+	ack := map[string]interface{}{"game_id": game.ID}
+	if result.State != nil {
+		ack["version"] = result.State.Version
 	}
-	h.broadcastResult(ctx, client, game.ID, result)
+	return ackEnvelope(msg.Type, ack)
 }
 
-// broadcastResult sends result.Events to the room and optionally a state envelope with the new state.
-func (h *EventHandler) broadcastResult(ctx context.Context, client *Client, gameID string, result games.ApplyMoveResult) {
+// broadcastResult sends result.Events to the room (private events only to their recipient) and each client its own view of the new state.
+func (h *EventHandler) broadcastResult(roomID, gameID string, result games.ApplyMoveResult) {
 	if h.hub == nil {
 		return
 	}
 	for _, ev := range result.Events {
 		envelope := &ServerEnvelope{Type: ServerTypeEvent, Event: ev.Event, Payload: ev.Payload}
-		h.hub.BroadcastEnvelope(client.RoomID, envelope)
+		if ev.To != "" {
+			h.hub.SendEnvelopeToPlayer(roomID, ev.To, envelope)
+			continue
+		}
+		h.hub.BroadcastEnvelope(roomID, envelope)
 	}
 	if result.State != nil {
-		statePayload := map[string]interface{}{
-			"game_id": gameID,
-			"state":   result.State.ToMap(),
-			"phase":   result.State.Phase,
-			"version": result.State.Version,
-		}
-		h.hub.BroadcastEnvelope(client.RoomID, &ServerEnvelope{Type: ServerTypeState, Event: ServerEventState, Payload: statePayload})
+		h.hub.BroadcastState(roomID, gameID, result.State)
+	}
+}
+
+// ackEnvelope builds the ack for a handled message of msgType; fields (may be nil) describe the outcome.
+func ackEnvelope(msgType string, fields map[string]interface{}) *ServerEnvelope {
+	payload := map[string]interface{}{"type": msgType}
+	for k, v := range fields {
+		payload[k] = v
 	}
+	return &ServerEnvelope{Type: ServerTypeAck, Payload: payload}
 }
 
-func sendErrorToClient(client *Client, message string) {
-	sendEnvelopeToClient(client, &ServerEnvelope{Type: ServerTypeError, Payload: map[string]interface{}{"message": message}})
+// errorEnvelope builds an error envelope with a stable code and a human-readable message.
+func errorEnvelope(code string, message string) *ServerEnvelope {
+	return &ServerEnvelope{Type: ServerTypeError, Payload: map[string]interface{}{"code": code, "message": message}}
 }
 
+// moveErrorEnvelope builds the error envelope for a rejected move: code, message and details (see games.ErrorCode).
+// Unexpected errors (code INTERNAL) are logged and sent without their text, which may describe the database.
+func moveErrorEnvelope(client *Client, err error) *ServerEnvelope {
+	code := games.ErrorCode(err)
+	payload := map[string]interface{}{"code": code, "message": err.Error()}
+	if code == games.CodeInternal {
+		log.Printf("move failed room_id=%s player_id=%s: %v", client.RoomID, client.RoomPlayerID, err)
+		payload["message"] = "internal error"
+	}
+	if details := games.ErrorDetails(err); details != nil {
+		payload["details"] = details
+	}
+	return &ServerEnvelope{Type: ServerTypeError, Payload: payload}
+}
+
+// sendEnvelopeToClient queues envelope for client only (see Client.queue).
 func sendEnvelopeToClient(client *Client, envelope *ServerEnvelope) {
-	select {
-	case client.send <- &OutgoingMessage{Envelope: envelope}:
-	default:
-		log.Printf("could not send envelope to client (channel full)")
-	}
+	client.queue(&OutgoingMessage{Envelope: envelope})
 }
 
 // handleChat persists (optional) and broadcasts a chat message to the room.
-func (h *EventHandler) handleChat(ctx context.Context, client *Client, msg *ClientInMessage) {
+func (h *EventHandler) handleChat(ctx context.Context, client *Client, msg *ClientInMessage) *ServerEnvelope {
 	if h.rateLimiter != nil && client.RateLimitKey != "" {
 		allowed, _ := h.rateLimiter.Allow(client.RateLimitKey)
 		if !allowed {
-			sendErrorToClient(client, "rate limit exceeded; try again later")
-			return
+			return errorEnvelope(ErrorCodeRateLimited, "rate limit exceeded; try again later")
 		}
 	}
 	var message string
@@ -201,15 +331,15 @@ func (h *EventHandler) handleChat(ctx context.Context, client *Client, msg *Clie
 	}
 	message = trimToMax(message, MaxChatMessageLength)
 	if message == "" {
-		return
+		return errorEnvelope(ErrorCodeInvalidMessage, "message is required")
 	}
 	roomUUID, err := stringToUUID(client.RoomID)
 	if err != nil {
-		return
+		return errorEnvelope(ErrorCodeInvalidMessage, "invalid room")
 	}
 	playerUUID, err := stringToUUID(client.RoomPlayerID)
 	if err != nil {
-		return
+		return errorEnvelope(ErrorCodeInvalidMessage, "invalid room player")
 	}
 	// Optional: persist to chat_messages (room-level chat, no game_id)
 	_, _ = h.queries.CreateChatMessage(ctx, db.CreateChatMessageParams{
@@ -227,6 +357,7 @@ func (h *EventHandler) handleChat(ctx context.Context, client *Client, msg *Clie
 		},
 	}
 	h.hub.BroadcastEnvelopeExcept(client.RoomID, envelope, client)
+	return ackEnvelope(msg.Type, nil)
 }
 
 func trimToMax(s string, max int) string {
@@ -236,35 +367,6 @@ func trimToMax(s string, max int) string {
 	return s[:max]
 }
 
-// HandleEvent processes an incoming event from a client.
-func (h *EventHandler) HandleEvent(ctx context.Context, client *Client, eventReq *store.CreateGameEventRequest) {
-	// Validate game exists and get room_id
-	gameUUID, err := stringToUUID(eventReq.GameID)
-	if err != nil {
-		log.Printf("game event: invalid game_id=%q: %v", eventReq.GameID, err)
-		return
-	}
-
-	game, err := h.queries.GetGameById(ctx, gameUUID)
-	if err != nil {
-		log.Printf("game event: game_id=%s not found: %v", eventReq.GameID, err)
-		return
-	}
-
-	roomID := uuidToString(game.RoomID)
-
-	// Create event in database
-	event, err := h.eventStore.CreateGameEvent(ctx, *eventReq)
-	if err != nil {
-		log.Printf("game event: game_id=%s room_id=%s error creating event: %v", eventReq.GameID, roomID, err)
-		return
-	}
-
-	// Broadcast to all clients in the room except the sender (do not log payload; may contain sensitive data)
-	h.hub.BroadcastExcept(roomID, event, client)
-	log.Printf("broadcast game_id=%s room_id=%s event_id=%s type=%s", eventReq.GameID, roomID, event.ID, event.Type)
-}
-
 // Helper function to convert string to UUID
 func stringToUUID(s string) (pgtype.UUID, error) {
 	id, err := uuid.Parse(s)
@@ -276,15 +378,3 @@ func stringToUUID(s string) (pgtype.UUID, error) {
 	u.Valid = true
 	return u, nil
 }
-
-// Helper function to convert UUID to string
-func uuidToString(u pgtype.UUID) string {
-	if !u.Valid {
-		return ""
-	}
-	id, err := uuid.FromBytes(u.Bytes[:])
-	if err != nil {
-		return ""
-	}
-	return id.String()
-}
@@ -1,9 +1,13 @@
 package websocket
 
 import (
+	"context"
 	"log"
 	"sync"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/vntrieu/avalon/internal/games"
 	"github.com/vntrieu/avalon/internal/store"
 )
 
@@ -24,27 +28,66 @@ type Hub struct {
 	// Event handler for processing events
 	eventHandler *EventHandler
 
+	// Optional: carries broadcasts to and from other server instances
+	backend Backend
+
+	// Room members on all instances by room_id -> room_player_id (see presence.go)
+	roster map[string]map[string]*presence
+
+	// Other instances' connections by room_id -> instance, from their PresenceCounts
+	remote map[string]map[string]*remoteCounts
+
+	// This instance's ID in PresenceCounts
+	instance string
+
+	// Offline members whose PresenceTimeout has passed
+	expire chan presenceExpiry
+
+	// How long an offline member stays in the roster
+	presenceTimeout time.Duration
+
+	// How often this instance's connection counts are republished
+	presenceRefresh time.Duration
+
+	// Numbered recent broadcasts and sessions by room_id, for resuming sessions (see replay.go)
+	replay map[string]*roomReplay
+
+	// What happened to messages slow clients could not take (see outbound.go)
+	outbound outboundCounters
+
 	// Mutex for thread-safe access
 	mu sync.RWMutex
 }
 
 // BroadcastMessage represents a message to be broadcast to a room.
-// Exactly one of Event or Envelope should be set.
+// Exactly one of Event, Envelope or View should be set.
 type BroadcastMessage struct {
 	RoomID        string
-	Event         *store.GameEvent  // for game WS
-	Envelope      *ServerEnvelope   // for room WS (e.g. chat)
-	ExcludeClient *Client           // Optional: exclude this client from the broadcast
+	Event         *store.GameEvent // for game WS
+	Envelope      *ServerEnvelope  // for room WS (e.g. chat)
+	View          ViewFunc         // for room WS: builds each client's own envelope (e.g. redacted state)
+	ExcludeClient *Client          // Optional: exclude this client from the broadcast
+	ToPlayerID    string           // Optional: deliver only to this room_player_id's clients
 }
 
+// ViewFunc builds the envelope a given client should receive; nil skips that client.
+type ViewFunc func(client *Client) *ServerEnvelope
+
 // NewHub creates a new Hub.
 func NewHub(eventHandler *EventHandler) *Hub {
 	return &Hub{
-		rooms:        make(map[string]map[*Client]bool),
-		broadcast:    make(chan *BroadcastMessage, 256),
-		register:     make(chan *Client),
-		unregister:   make(chan *Client),
-		eventHandler: eventHandler,
+		rooms:           make(map[string]map[*Client]bool),
+		broadcast:       make(chan *BroadcastMessage, 256),
+		register:        make(chan *Client),
+		unregister:      make(chan *Client),
+		eventHandler:    eventHandler,
+		roster:          make(map[string]map[string]*presence),
+		remote:          make(map[string]map[string]*remoteCounts),
+		instance:        uuid.NewString(),
+		expire:          make(chan presenceExpiry),
+		presenceTimeout: PresenceTimeout,
+		presenceRefresh: PresenceRefresh,
+		replay:          make(map[string]*roomReplay),
 	}
 }
 
@@ -55,69 +98,175 @@ func (h *Hub) SetEventHandler(handler *EventHandler) {
 	h.eventHandler = handler
 }
 
+// SetBackend makes the hub publish its broadcasts through backend and deliver other instances' broadcasts to its
+// own clients. The backend runs until ctx is done.
+func (h *Hub) SetBackend(ctx context.Context, backend Backend) {
+	h.mu.Lock()
+	h.backend = backend
+	h.mu.Unlock()
+	go backend.Run(ctx, h.deliverRemote)
+}
+
+// publish hands msg to the backend, if any.
+func (h *Hub) publish(msg *RemoteBroadcast) {
+	h.mu.RLock()
+	backend := h.backend
+	h.mu.RUnlock()
+	if backend != nil {
+		backend.Publish(msg)
+	}
+}
+
+// deliverRemote broadcasts another instance's message to this instance's clients, or records its connection counts.
+func (h *Hub) deliverRemote(msg *RemoteBroadcast) {
+	if msg.Presence != nil {
+		h.mu.Lock()
+		changes := h.applyCounts(msg.RoomID, msg.Presence, time.Now())
+		handler := h.eventHandler
+		h.mu.Unlock()
+		notifyConnections(handler, changes)
+		return
+	}
+	local := &BroadcastMessage{RoomID: msg.RoomID, Event: msg.Event, Envelope: msg.Envelope, ToPlayerID: msg.ToPlayerID}
+	if msg.State != nil {
+		state, gameID := games.StateFromMap(msg.State), msg.GameID
+		local.View = func(client *Client) *ServerEnvelope {
+			return stateEnvelope(gameID, state, client)
+		}
+	}
+	h.broadcast <- local
+}
+
 // Run starts the hub's main loop.
 func (h *Hub) Run() {
+	refresh := time.NewTicker(h.presenceRefresh)
+	defer refresh.Stop()
 	for {
 		select {
 		case client := <-h.register:
 			h.mu.Lock()
-			if h.rooms[client.RoomID] == nil {
+			following := h.rooms[client.RoomID] != nil
+			if !following {
 				h.rooms[client.RoomID] = make(map[*Client]bool)
 			}
 			h.rooms[client.RoomID][client] = true
+			resync := h.openSession(client)
+			var changes []connectionChange
+			if h.reconcile(client.RoomID, memberOf(client), client) {
+				changes = append(changes, connectionChange{roomID: client.RoomID, playerID: client.RoomPlayerID})
+			}
+			h.publishCounts(client.RoomID, !following)
+			handler := h.eventHandler
+			total := len(h.rooms[client.RoomID])
 			h.mu.Unlock()
-			log.Printf("ws client registered room_id=%s player_id=%s total=%d", client.RoomID, client.RoomPlayerID, len(h.rooms[client.RoomID]))
+			log.Printf("ws client registered room_id=%s player_id=%s total=%d", client.RoomID, client.RoomPlayerID, total)
+			notifyConnections(handler, changes)
+			if resync && handler != nil {
+				go handler.Resync(client)
+			}
 
 		case client := <-h.unregister:
 			h.mu.Lock()
+			var changes []connectionChange
 			if room, ok := h.rooms[client.RoomID]; ok {
 				if _, ok := room[client]; ok {
 					delete(room, client)
-					close(client.send)
+					client.closeSend()
 					if len(room) == 0 {
 						delete(h.rooms, client.RoomID)
 					}
+					if h.reconcile(client.RoomID, memberOf(client), nil) {
+						changes = append(changes, connectionChange{roomID: client.RoomID, playerID: client.RoomPlayerID})
+					}
+					h.publishCounts(client.RoomID, false)
 				}
 			}
+			h.forgetRoom(client.RoomID)
+			handler := h.eventHandler
 			h.mu.Unlock()
 			log.Printf("ws client unregistered room_id=%s player_id=%s", client.RoomID, client.RoomPlayerID)
+			notifyConnections(handler, changes)
 
-		case message := <-h.broadcast:
-			h.mu.RLock()
-			room, exists := h.rooms[message.RoomID]
-			if exists {
-				var out *OutgoingMessage
-				if message.Event != nil {
-					out = &OutgoingMessage{GameEvent: message.Event}
-				} else if message.Envelope != nil {
-					out = &OutgoingMessage{Envelope: message.Envelope}
-				}
-				for client := range room {
-					if out == nil {
-						continue
-					}
-					if message.ExcludeClient != nil && client == message.ExcludeClient {
-						continue
-					}
-					select {
-					case client.send <- out:
-					default:
-						close(client.send)
-						delete(room, client)
-					}
-				}
+		case expired := <-h.expire:
+			h.mu.Lock()
+			announce := h.markLeft(expired)
+			h.deliver(&BroadcastMessage{RoomID: expired.roomID, Envelope: announce})
+			if announce != nil {
+				h.dropSessions(expired.roomID, expired.playerID)
 			}
-			h.mu.RUnlock()
+			h.forgetRoom(expired.roomID)
+			h.mu.Unlock()
+
+		case message := <-h.broadcast:
+			h.mu.Lock()
+			h.deliver(message)
+			h.mu.Unlock()
+
+		case now := <-refresh.C:
+			h.mu.Lock()
+			changes := h.refreshCounts(now)
+			handler := h.eventHandler
+			h.mu.Unlock()
+			notifyConnections(handler, changes)
+		}
+	}
+}
+
+// deliver queues message for its clients in the room (see Client.queue). Envelope and view broadcasts are
+// numbered and kept for replay (see roomReplay), also while the room has no clients here, so a player whose
+// only connection dropped gets them on resuming. Clients that fall behind stay in the room until their
+// connection closes and they unregister. The caller holds h.mu.
+func (h *Hub) deliver(message *BroadcastMessage) {
+	if message.Event == nil && message.Envelope == nil && message.View == nil {
+		return // e.g. a presence change that announces nothing
+	}
+	var seq int64
+	if r := h.replay[message.RoomID]; r != nil && message.Event == nil {
+		seq = r.add(message)
+	}
+	room, exists := h.rooms[message.RoomID]
+	if !exists {
+		return
+	}
+	for client := range room {
+		clientOut := outgoingFor(message, seq, client)
+		if clientOut != nil {
+			client.queue(clientOut)
 		}
 	}
 }
 
+// outgoingFor returns what client should receive for message, with envelopes numbered seq,
+// or nil if message is not for client.
+func outgoingFor(message *BroadcastMessage, seq int64, client *Client) *OutgoingMessage {
+	if message.ExcludeClient != nil && client == message.ExcludeClient {
+		return nil
+	}
+	if message.ToPlayerID != "" && client.RoomPlayerID != message.ToPlayerID {
+		return nil
+	}
+	if message.Event != nil {
+		return &OutgoingMessage{GameEvent: message.Event}
+	}
+	envelope := message.Envelope
+	if message.View != nil {
+		envelope = message.View(client)
+	}
+	if envelope == nil {
+		return nil
+	}
+	numbered := *envelope
+	numbered.Seq = seq
+	return &OutgoingMessage{Envelope: &numbered}
+}
+
 // Broadcast sends a message to all clients in a room.
 func (h *Hub) Broadcast(roomID string, event *store.GameEvent) {
 	h.broadcast <- &BroadcastMessage{
 		RoomID: roomID,
 		Event:  event,
 	}
+	h.publish(&RemoteBroadcast{RoomID: roomID, Event: event})
 }
 
 // BroadcastExcept sends a message to all clients in a room except the specified client.
@@ -127,11 +276,13 @@ func (h *Hub) BroadcastExcept(roomID string, event *store.GameEvent, excludeClie
 		Event:         event,
 		ExcludeClient: excludeClient,
 	}
+	h.publish(&RemoteBroadcast{RoomID: roomID, Event: event})
 }
 
 // BroadcastEnvelope sends a server envelope to all clients in a room (e.g. chat).
 func (h *Hub) BroadcastEnvelope(roomID string, envelope *ServerEnvelope) {
 	h.broadcast <- &BroadcastMessage{RoomID: roomID, Envelope: envelope}
+	h.publish(&RemoteBroadcast{RoomID: roomID, Envelope: envelope})
 }
 
 // BroadcastEnvelopeExcept sends a server envelope to all clients in a room except the specified client.
@@ -141,6 +292,59 @@ func (h *Hub) BroadcastEnvelopeExcept(roomID string, envelope *ServerEnvelope, e
 		Envelope:      envelope,
 		ExcludeClient: excludeClient,
 	}
+	h.publish(&RemoteBroadcast{RoomID: roomID, Envelope: envelope})
+}
+
+// BroadcastView sends each client in a room the envelope view builds for it.
+// A ViewFunc cannot reach other instances; use BroadcastState for game state.
+func (h *Hub) BroadcastView(roomID string, view ViewFunc) {
+	h.broadcast <- &BroadcastMessage{RoomID: roomID, View: view}
+}
+
+// BroadcastState sends each client in a room, on every instance, its own view of the game state (see stateEnvelope).
+func (h *Hub) BroadcastState(roomID string, gameID string, state *games.GameState) {
+	h.BroadcastView(roomID, func(client *Client) *ServerEnvelope {
+		return stateEnvelope(gameID, state, client)
+	})
+	h.publish(&RemoteBroadcast{RoomID: roomID, GameID: gameID, State: state.ToMap()})
+}
+
+// SendEnvelopeToPlayer sends a server envelope only to the clients of roomPlayerID in a room (private events).
+func (h *Hub) SendEnvelopeToPlayer(roomID string, roomPlayerID string, envelope *ServerEnvelope) {
+	h.broadcast <- &BroadcastMessage{
+		RoomID:     roomID,
+		Envelope:   envelope,
+		ToPlayerID: roomPlayerID,
+	}
+	h.publish(&RemoteBroadcast{RoomID: roomID, Envelope: envelope, ToPlayerID: roomPlayerID})
+}
+
+// IsPlayerConnected reports whether roomPlayerID has at least one open room WS connection on any instance
+// (as far as this instance has heard; see PresenceCounts). Game WS and spectator connections do not count
+// (see playerSocket).
+func (h *Hub) IsPlayerConnected(roomID string, roomPlayerID string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	_, seats := h.connections(roomID, roomPlayerID)
+	return seats > 0
+}
+
+// playerSocket reports whether client is a player's room WS connection, the only kind the game counts as
+// connected: game WS clients are read-only followers and spectators have no seat.
+func playerSocket(client *Client) bool {
+	return client.GameID == "" && client.RoomPlayerID != "" && !client.Spectator
+}
+
+// notifyConnections tells the event handler about players whose first room WS connection on any instance opened
+// or last one closed, so the game can track who is connected. Every instance that sees the change notifies;
+// UpdateConnection only records real changes. Runs in its own goroutines to keep the hub loop off the database.
+func notifyConnections(handler *EventHandler, changes []connectionChange) {
+	if handler == nil {
+		return
+	}
+	for _, change := range changes {
+		go handler.UpdateConnection(change.roomID, change.playerID)
+	}
 }
 
 // GetRoomClientCount returns the number of clients in a room.
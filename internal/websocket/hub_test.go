@@ -2,9 +2,13 @@ package websocket
 
 import (
 	"context"
+	"encoding/json"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/vntrieu/avalon/internal/games"
 	"github.com/vntrieu/avalon/internal/store"
 )
 
@@ -152,6 +156,7 @@ func TestHub_Broadcast(t *testing.T) {
 
 	// Give hub time to process registration
 	time.Sleep(10 * time.Millisecond)
+	drainRegistration(clients)
 
 	// Create a test event
 	event := &store.GameEvent{
@@ -189,6 +194,328 @@ func TestHub_Broadcast(t *testing.T) {
 	}
 }
 
+func TestHub_SendEnvelopeToPlayer(t *testing.T) {
+	hub := NewHub(nil)
+	go hub.Run()
+
+	clients := make([]*Client, 3)
+	for i := 0; i < 3; i++ {
+		clients[i] = &Client{
+			hub:          hub,
+			send:         make(chan *OutgoingMessage, 256),
+			RoomID:       "room-1",
+			RoomPlayerID: "player-" + string(rune('1'+i)),
+			ctx:          context.Background(),
+		}
+		hub.register <- clients[i]
+	}
+	time.Sleep(10 * time.Millisecond)
+	drainRegistration(clients)
+
+	hub.SendEnvelopeToPlayer("room-1", "player-2", &ServerEnvelope{Type: ServerTypeEvent, Event: ServerEventLadyResult})
+	time.Sleep(10 * time.Millisecond)
+
+	for i, client := range clients {
+		select {
+		case out := <-client.send:
+			if i != 1 {
+				t.Errorf("client %d: received private envelope meant for player-2", i)
+			} else if out.Envelope == nil || out.Envelope.Event != ServerEventLadyResult {
+				t.Errorf("client %d: expected lady_result envelope, got %+v", i, out)
+			}
+		case <-time.After(50 * time.Millisecond):
+			if i == 1 {
+				t.Errorf("client %d: did not receive private envelope", i)
+			}
+		}
+	}
+}
+
+func TestHub_BroadcastView(t *testing.T) {
+	hub := NewHub(nil)
+	go hub.Run()
+
+	clients := make([]*Client, 2)
+	for i := 0; i < 2; i++ {
+		clients[i] = &Client{
+			hub:          hub,
+			send:         make(chan *OutgoingMessage, 256),
+			RoomID:       "room-1",
+			RoomPlayerID: "player-" + string(rune('1'+i)),
+			ctx:          context.Background(),
+		}
+		hub.register <- clients[i]
+	}
+	time.Sleep(10 * time.Millisecond)
+	drainRegistration(clients)
+
+	hub.BroadcastView("room-1", func(client *Client) *ServerEnvelope {
+		return &ServerEnvelope{Type: ServerTypeState, Event: ServerEventState, Payload: map[string]interface{}{"viewer": client.RoomPlayerID}}
+	})
+	time.Sleep(10 * time.Millisecond)
+
+	for i, client := range clients {
+		select {
+		case out := <-client.send:
+			if out.Envelope == nil || out.Envelope.Payload["viewer"] != client.RoomPlayerID {
+				t.Errorf("client %d: expected its own view, got %+v", i, out.Envelope)
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Errorf("client %d: did not receive view", i)
+		}
+	}
+}
+
+// fakeBackend records published broadcasts and hands the hub's deliver func to the test.
+type fakeBackend struct {
+	mu        sync.Mutex
+	published []*RemoteBroadcast
+	deliver   chan func(*RemoteBroadcast)
+}
+
+func (b *fakeBackend) Publish(msg *RemoteBroadcast) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.published = append(b.published, msg)
+}
+
+func (b *fakeBackend) Run(ctx context.Context, deliver func(*RemoteBroadcast)) {
+	b.deliver <- deliver
+}
+
+func TestHub_Backend(t *testing.T) {
+	hub := NewHub(nil)
+	go hub.Run()
+	backend := &fakeBackend{deliver: make(chan func(*RemoteBroadcast), 1)}
+	hub.SetBackend(context.Background(), backend)
+	deliver := <-backend.deliver
+
+	clients := make([]*Client, 2)
+	for i := 0; i < 2; i++ {
+		clients[i] = &Client{
+			hub:          hub,
+			send:         make(chan *OutgoingMessage, 256),
+			RoomID:       "room-1",
+			RoomPlayerID: "player-" + string(rune('1'+i)),
+			ctx:          context.Background(),
+		}
+		hub.register <- clients[i]
+	}
+	time.Sleep(10 * time.Millisecond)
+	drainRegistration(clients)
+	backend.mu.Lock()
+	for _, msg := range backend.published {
+		if msg.Presence == nil || msg.Event != nil || msg.Envelope != nil {
+			t.Errorf("expected only connection counts published on registration, got %+v", msg)
+		}
+	}
+	if last := backend.published[len(backend.published)-1].Presence; len(last.Members) != 2 || last.Members[1].Sockets != 1 {
+		t.Errorf("expected both players counted, got %+v", last)
+	}
+	backend.published = nil
+	backend.mu.Unlock()
+
+	state := &games.GameState{GameID: "game-1", Phase: games.PhaseTeamSelection, Status: "in_progress", Version: 3,
+		PlayerIDs: []string{"player-1", "player-2"}, Roles: map[string]string{"player-1": games.RoleMerlin, "player-2": games.RoleAssassin}}
+	hub.BroadcastEnvelopeExcept("room-1", &ServerEnvelope{Type: ServerTypeEvent, Event: ServerEventChat}, clients[0])
+	hub.BroadcastState("room-1", "game-1", state)
+	time.Sleep(10 * time.Millisecond)
+
+	backend.mu.Lock()
+	published := backend.published
+	backend.mu.Unlock()
+	if len(published) != 2 || published[0].Envelope == nil || published[1].State == nil || published[1].GameID != "game-1" {
+		t.Fatalf("expected the chat and the state published, got %+v", published)
+	}
+	for _, client := range clients {
+		for len(client.send) > 0 {
+			<-client.send
+		}
+	}
+
+	// A state from another instance, as decoded off the wire, is turned into each client's own view.
+	body, _ := json.Marshal(published[1])
+	var remote RemoteBroadcast
+	if err := json.Unmarshal(body, &remote); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	deliver(&remote)
+	time.Sleep(10 * time.Millisecond)
+	for i, client := range clients {
+		select {
+		case out := <-client.send:
+			view, _ := out.Envelope.Payload["state"].(map[string]interface{})
+			if out.Envelope.Type != ServerTypeState || view["my_role"] != state.Roles[client.RoomPlayerID] {
+				t.Errorf("client %d: expected its own view of the state, got %+v", i, out.Envelope)
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Errorf("client %d: did not receive remote state", i)
+		}
+	}
+}
+
+// bus links hubs in a test as if they were server instances sharing a backend: what one publishes reaches
+// the others in order, as decoded off the wire.
+type bus struct {
+	mu      sync.Mutex
+	members []*busBackend
+}
+
+type busBackend struct {
+	bus   *bus
+	inbox chan []byte
+}
+
+func (b *bus) join(ctx context.Context, hub *Hub) *busBackend {
+	m := &busBackend{bus: b, inbox: make(chan []byte, 256)}
+	b.mu.Lock()
+	b.members = append(b.members, m)
+	b.mu.Unlock()
+	hub.SetBackend(ctx, m)
+	return m
+}
+
+// leave cuts m off, as if its instance stopped.
+func (b *bus) leave(m *busBackend) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, other := range b.members {
+		if other == m {
+			b.members = append(b.members[:i], b.members[i+1:]...)
+			return
+		}
+	}
+}
+
+func (m *busBackend) Publish(msg *RemoteBroadcast) {
+	body, _ := json.Marshal(msg)
+	m.bus.mu.Lock()
+	defer m.bus.mu.Unlock()
+	for _, other := range m.bus.members {
+		if other != m {
+			other.inbox <- body
+		}
+	}
+}
+
+func (m *busBackend) Run(ctx context.Context, deliver func(*RemoteBroadcast)) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case body := <-m.inbox:
+			var msg RemoteBroadcast
+			if err := json.Unmarshal(body, &msg); err == nil {
+				deliver(&msg)
+			}
+		}
+	}
+}
+
+func TestHub_PresenceAcrossInstances(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	shared := &bus{}
+	hubA, hubB := NewHub(nil), NewHub(nil)
+	hubA.presenceTimeout, hubB.presenceTimeout = time.Minute, time.Minute
+	hubB.presenceRefresh = 20 * time.Millisecond
+	go hubA.Run()
+	go hubB.Run()
+	a := shared.join(ctx, hubA)
+	shared.join(ctx, hubB)
+
+	newClient := func(hub *Hub, playerID string) *Client {
+		return &Client{hub: hub, send: make(chan *OutgoingMessage, 256), RoomID: "room-1", RoomPlayerID: playerID, DisplayName: playerID, ctx: context.Background()}
+	}
+	observer := newClient(hubB, "observer")
+	hubB.register <- observer
+	time.Sleep(10 * time.Millisecond)
+	drainRegistration([]*Client{observer})
+	expect := func(event string) {
+		t.Helper()
+		select {
+		case out := <-observer.send:
+			if out.Envelope == nil || out.Envelope.Event != event || out.Envelope.Payload["player_id"] != "player-1" {
+				t.Errorf("expected %s for player-1, got %+v", event, out.Envelope)
+			}
+		case <-time.After(200 * time.Millisecond):
+			t.Errorf("expected %s", event)
+		}
+	}
+	expectNothing := func() {
+		t.Helper()
+		time.Sleep(10 * time.Millisecond)
+		if len(observer.send) > 0 {
+			t.Errorf("expected no presence event, got %+v", (<-observer.send).Envelope)
+		}
+	}
+
+	// A tab on each instance: online with the first, offline only when both are closed.
+	tabA, tabB := newClient(hubA, "player-1"), newClient(hubB, "player-1")
+	hubA.register <- tabA
+	expect(ServerEventPlayerJoined)
+	if roster := hubB.Roster("room-1"); len(roster) != 2 || roster[1].PlayerID != "player-1" || !roster[1].Online {
+		t.Errorf("expected player-1 online in B's roster, got %+v", roster)
+	}
+	hubB.register <- tabB
+	expectNothing()
+	hubA.unregister <- tabA
+	expectNothing()
+	hubB.unregister <- tabB
+	expect(ServerEventPlayerOffline)
+
+	// An instance that stops publishing takes its connections with it.
+	hubA.register <- newClient(hubA, "player-1")
+	expect(ServerEventPlayerOnline)
+	shared.leave(a)
+	expect(ServerEventPlayerOffline)
+}
+
+func TestHub_IsPlayerConnectedAcrossInstances(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	shared := &bus{}
+	hubA, hubB := NewHub(nil), NewHub(nil)
+	go hubA.Run()
+	go hubB.Run()
+	shared.join(ctx, hubA)
+	shared.join(ctx, hubB)
+
+	newClient := func(hub *Hub, gameID string) *Client {
+		return &Client{hub: hub, send: make(chan *OutgoingMessage, 256), RoomID: "room-1", GameID: gameID, RoomPlayerID: "player-1", ctx: context.Background()}
+	}
+	expect := func(want bool) {
+		t.Helper()
+		time.Sleep(20 * time.Millisecond)
+		for name, hub := range map[string]*Hub{"A": hubA, "B": hubB} {
+			if got := hub.IsPlayerConnected("room-1", "player-1"); got != want {
+				t.Errorf("hub %s: expected connected=%v, got %v", name, want, got)
+			}
+		}
+	}
+
+	// A room socket on either instance keeps the player connected on both; the game socket never does.
+	roomA, roomB, gameB := newClient(hubA, ""), newClient(hubB, ""), newClient(hubB, "game-1")
+	hubB.register <- gameB
+	expect(false)
+	hubA.register <- roomA
+	expect(true)
+	hubB.register <- roomB
+	hubA.unregister <- roomA
+	expect(true)
+	hubB.unregister <- roomB
+	expect(false)
+}
+
+// drainRegistration discards the session and presence events clients received while the test registered them.
+func drainRegistration(clients []*Client) {
+	for _, client := range clients {
+		for len(client.send) > 0 {
+			<-client.send
+		}
+	}
+}
+
 func TestHub_BroadcastToSpecificRoom(t *testing.T) {
 	hub := NewHub(nil)
 	go hub.Run()
@@ -216,6 +543,7 @@ func TestHub_BroadcastToSpecificRoom(t *testing.T) {
 
 	// Give hub time to process
 	time.Sleep(10 * time.Millisecond)
+	drainRegistration([]*Client{room1Client, room2Client})
 
 	// Broadcast event to room-1 only
 	event := &store.GameEvent{
@@ -306,3 +634,269 @@ func TestHub_ConcurrentRegistration(t *testing.T) {
 		t.Errorf("expected 10 clients in room, got %d", count)
 	}
 }
+
+func TestHub_Presence(t *testing.T) {
+	hub := NewHub(nil)
+	hub.presenceTimeout = 30 * time.Millisecond
+	go hub.Run()
+
+	newClient := func(playerID string) *Client {
+		return &Client{hub: hub, send: make(chan *OutgoingMessage, 256), RoomID: "room-1", RoomPlayerID: playerID, DisplayName: playerID, ctx: context.Background()}
+	}
+	observer := newClient("observer")
+	hub.register <- observer
+	time.Sleep(10 * time.Millisecond)
+	drainRegistration([]*Client{observer})
+	expect := func(event string) {
+		t.Helper()
+		select {
+		case out := <-observer.send:
+			if out.Envelope == nil || out.Envelope.Event != event || out.Envelope.Payload["player_id"] != "player-1" {
+				t.Errorf("expected %s for player-1, got %+v", event, out.Envelope)
+			}
+		case <-time.After(200 * time.Millisecond):
+			t.Errorf("expected %s", event)
+		}
+	}
+	expectNothing := func() {
+		t.Helper()
+		time.Sleep(10 * time.Millisecond)
+		if len(observer.send) > 0 {
+			t.Errorf("expected no presence event, got %+v", (<-observer.send).Envelope)
+		}
+	}
+
+	// Two tabs: online with the first, offline only when both are closed.
+	tab1, tab2 := newClient("player-1"), newClient("player-1")
+	hub.register <- tab1
+	expect(ServerEventPlayerJoined)
+	hub.register <- tab2
+	expectNothing()
+	hub.unregister <- tab1
+	expectNothing()
+	hub.unregister <- tab2
+	expect(ServerEventPlayerOffline)
+	if roster := hub.Roster("room-1"); len(roster) != 2 || roster[1].PlayerID != "player-1" || roster[1].Online || roster[1].OfflineSince == "" {
+		t.Errorf("expected player-1 offline in the roster, got %+v", roster)
+	}
+
+	// Back within the timeout: online again; then gone for good.
+	tab3 := newClient("player-1")
+	hub.register <- tab3
+	expect(ServerEventPlayerOnline)
+	hub.unregister <- tab3
+	expect(ServerEventPlayerOffline)
+	expect(ServerEventPlayerLeft)
+	if roster := hub.Roster("room-1"); len(roster) != 1 || roster[0].PlayerID != "observer" || !roster[0].Online {
+		t.Errorf("expected only the observer left in the roster, got %+v", roster)
+	}
+}
+
+func TestHub_ResumeSession(t *testing.T) {
+	hub := NewHub(nil)
+	go hub.Run()
+
+	newClient := func(playerID string) *Client {
+		return &Client{hub: hub, send: make(chan *OutgoingMessage, 256), RoomID: "room-1", RoomPlayerID: playerID, DisplayName: playerID, ctx: context.Background()}
+	}
+	received := func(client *Client) []*ServerEnvelope {
+		time.Sleep(10 * time.Millisecond)
+		var envelopes []*ServerEnvelope
+		for len(client.send) > 0 {
+			envelopes = append(envelopes, (<-client.send).Envelope)
+		}
+		return envelopes
+	}
+	observer := newClient("observer")
+	hub.register <- observer
+	tab := newClient("player-1")
+	hub.register <- tab
+	first := received(tab)
+	if len(first) != 1 || first[0].Event != ServerEventSession || first[0].Payload["resumed"] != false {
+		t.Fatalf("expected a new session, got %+v", first)
+	}
+	sessionID := tab.SessionID
+	if first[0].Payload["session_id"] != sessionID || sessionID == "" {
+		t.Fatalf("expected session_id %q, got %+v", sessionID, first[0].Payload)
+	}
+
+	chat := func(text string) *ServerEnvelope {
+		return &ServerEnvelope{Type: ServerTypeEvent, Event: ServerEventChat, Payload: map[string]interface{}{"message": text}}
+	}
+	hub.BroadcastEnvelope("room-1", chat("seen"))
+	seen := received(tab)
+	if len(seen) != 1 || seen[0].Seq == 0 {
+		t.Fatalf("expected a numbered chat, got %+v", seen)
+	}
+
+	// Missed while disconnected: public chat, own vote_recorded, another player's private event.
+	hub.unregister <- tab
+	hub.BroadcastEnvelope("room-1", chat("missed"))
+	hub.SendEnvelopeToPlayer("room-1", "player-1", &ServerEnvelope{Type: ServerTypeEvent, Event: ServerEventVoteRecorded})
+	hub.SendEnvelopeToPlayer("room-1", "player-2", &ServerEnvelope{Type: ServerTypeEvent, Event: ServerEventLadyResult})
+	time.Sleep(10 * time.Millisecond)
+
+	resumed := newClient("player-1")
+	resumed.SessionID, resumed.resuming, resumed.lastSeq = sessionID, true, seen[0].Seq
+	hub.register <- resumed
+	var events []string
+	for _, env := range received(resumed) {
+		events = append(events, env.Event)
+	}
+	want := []string{ServerEventSession, ServerEventPlayerOffline, ServerEventChat, ServerEventVoteRecorded}
+	if strings.Join(events, ",") != strings.Join(want, ",") {
+		t.Errorf("expected replay %v, got %v", want, events)
+	}
+	if resumed.SessionID != sessionID {
+		t.Errorf("expected session %q kept, got %q", sessionID, resumed.SessionID)
+	}
+
+	// Another player cannot take over the session.
+	other := newClient("player-2")
+	other.SessionID, other.resuming, other.lastSeq = sessionID, true, seen[0].Seq
+	hub.register <- other
+	got := received(other)
+	if len(got) != 1 || got[0].Event != ServerEventSession || got[0].Payload["resumed"] != false || other.SessionID == sessionID {
+		t.Errorf("expected a new session for player-2, got %+v", got)
+	}
+}
+
+func TestRoomReplay_Since(t *testing.T) {
+	r := &roomReplay{sessions: make(map[string]string)}
+	if _, ok := r.since(0); !ok {
+		t.Error("expected an empty room to be covered from seq 0")
+	}
+	for i := 0; i < ReplayBufferSize+10; i++ {
+		r.add(&BroadcastMessage{RoomID: "room-1", Envelope: &ServerEnvelope{Type: ServerTypeEvent}})
+	}
+	last := int64(ReplayBufferSize + 10)
+	if entries, ok := r.since(last - 3); !ok || len(entries) != 3 || entries[0].seq != last-2 {
+		t.Errorf("expected the last 3 entries, got %d ok=%v", len(entries), ok)
+	}
+	if entries, ok := r.since(last); !ok || len(entries) != 0 {
+		t.Errorf("expected nothing missed, got %d ok=%v", len(entries), ok)
+	}
+	if _, ok := r.since(10); !ok {
+		t.Error("expected seq 10 to be covered: the oldest kept entry is 11")
+	}
+	if _, ok := r.since(9); ok {
+		t.Error("expected seq 9 not to be covered")
+	}
+	if _, ok := r.since(last + 1); ok {
+		t.Error("expected a future seq not to be covered")
+	}
+}
+
+func TestHub_SlowClient(t *testing.T) {
+	hub := NewHub(nil)
+	go hub.Run()
+
+	client := &Client{hub: hub, send: make(chan *OutgoingMessage, 2), RoomID: "room-1", RoomPlayerID: "player-1", ctx: context.Background()}
+	hub.register <- client
+	time.Sleep(10 * time.Millisecond)
+	drainRegistration([]*Client{client})
+
+	chat := &ServerEnvelope{Type: ServerTypeEvent, Event: ServerEventChat}
+	state := func(version int) *ServerEnvelope {
+		return &ServerEnvelope{Type: ServerTypeState, Event: ServerEventState, Payload: map[string]interface{}{"version": version}}
+	}
+
+	// A full buffer keeps only the latest state beside it.
+	hub.BroadcastEnvelope("room-1", chat)
+	hub.BroadcastEnvelope("room-1", chat)
+	hub.BroadcastEnvelope("room-1", state(1))
+	hub.BroadcastEnvelope("room-1", state(2))
+	time.Sleep(20 * time.Millisecond)
+	if stats := hub.OutboundStats(); stats != (OutboundStats{Merged: 1}) {
+		t.Errorf("expected one merged state, got %+v", stats)
+	}
+	<-client.send
+	<-client.send
+	if pending := client.takePending(); pending == nil || pending.Envelope.Payload["version"] != 2 {
+		t.Errorf("expected the pending state to be version 2, got %+v", pending)
+	}
+
+	// Any other message that does not fit evicts the client; later ones are dropped.
+	for i := 0; i < 4; i++ {
+		hub.BroadcastEnvelope("room-1", chat)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if stats := hub.OutboundStats(); stats != (OutboundStats{Merged: 1, Dropped: 2, Evicted: 1}) {
+		t.Errorf("expected the client evicted with two drops, got %+v", stats)
+	}
+	if hub.GetRoomClientCount("room-1") != 1 {
+		t.Error("expected the evicted client to stay registered until its connection closes")
+	}
+
+	// Replies after the client unregistered are dropped, not sent on the closed channel.
+	hub.unregister <- client
+	time.Sleep(10 * time.Millisecond)
+	sendEnvelopeToClient(client, chat)
+	if stats := hub.OutboundStats(); stats.Dropped != 3 {
+		t.Errorf("expected a third drop, got %+v", stats)
+	}
+}
+
+func TestHub_ResumeSession_OnlyClient(t *testing.T) {
+	hub := NewHub(nil)
+	go hub.Run()
+
+	newClient := func() *Client {
+		return &Client{hub: hub, send: make(chan *OutgoingMessage, 256), RoomID: "room-1", RoomPlayerID: "player-1", DisplayName: "player-1", ctx: context.Background()}
+	}
+	tab := newClient()
+	hub.register <- tab
+	time.Sleep(10 * time.Millisecond)
+	drainRegistration([]*Client{tab})
+	sessionID := tab.SessionID
+
+	// The room has no clients on this instance while the player is away.
+	hub.unregister <- tab
+	hub.BroadcastEnvelope("room-1", &ServerEnvelope{Type: ServerTypeEvent, Event: ServerEventChat})
+	time.Sleep(10 * time.Millisecond)
+
+	resumed := newClient()
+	resumed.SessionID, resumed.resuming, resumed.lastSeq = sessionID, true, 0
+	hub.register <- resumed
+	time.Sleep(10 * time.Millisecond)
+	var events []string
+	for len(resumed.send) > 0 {
+		events = append(events, (<-resumed.send).Envelope.Event)
+	}
+	want := []string{ServerEventSession, ServerEventPlayerOffline, ServerEventChat}
+	if strings.Join(events, ",") != strings.Join(want, ",") {
+		t.Errorf("expected replay %v, got %v", want, events)
+	}
+}
+
+func TestHub_IsPlayerConnected(t *testing.T) {
+	hub := NewHub(nil)
+	go hub.Run()
+
+	newClient := func(gameID string) *Client {
+		return &Client{hub: hub, send: make(chan *OutgoingMessage, 256), RoomID: "room-1", GameID: gameID, RoomPlayerID: "player-1", ctx: context.Background()}
+	}
+	expect := func(want bool) {
+		t.Helper()
+		time.Sleep(10 * time.Millisecond)
+		if got := hub.IsPlayerConnected("room-1", "player-1"); got != want {
+			t.Errorf("expected connected=%v, got %v", want, got)
+		}
+	}
+
+	// Only room WS connections count; the read-only game WS does not.
+	gameSocket, roomSocket := newClient("game-1"), newClient("")
+	hub.register <- gameSocket
+	expect(false)
+	hub.register <- roomSocket
+	expect(true)
+	hub.unregister <- roomSocket
+	expect(false)
+	hub.unregister <- gameSocket
+	expect(false)
+
+	spectator := newClient("")
+	spectator.Spectator = true
+	hub.register <- spectator
+	expect(false)
+}
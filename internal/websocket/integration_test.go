@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
-	"sync"
 	"testing"
 	"time"
 
@@ -79,7 +78,7 @@ func TestWebSocketConnection(t *testing.T) {
 	}
 }
 
-func TestWebSocketEventSending(t *testing.T) {
+func TestWebSocketGameSocketReadOnly(t *testing.T) {
 	pool := store.SetupTestDB(t)
 	defer pool.Close()
 
@@ -149,7 +148,7 @@ func TestWebSocketEventSending(t *testing.T) {
 	// Give time for registration
 	time.Sleep(50 * time.Millisecond)
 
-	// Send an event
+	// Send an event: the game socket is read-only, so it is rejected
 	eventReq := store.CreateGameEventRequest{
 		GameID: gameResp.Game.ID,
 		Type:   "test_event",
@@ -167,10 +166,12 @@ func TestWebSocketEventSending(t *testing.T) {
 		t.Fatalf("failed to write message: %v", err)
 	}
 
-	// Give time for event processing
-	time.Sleep(100 * time.Millisecond)
+	reply := readReply(t, conn)
+	if reply.Type != ServerTypeError || reply.Payload["code"] != ErrorCodeReadOnly {
+		t.Errorf("expected a %s error, got %+v", ErrorCodeReadOnly, reply)
+	}
 
-	// Verify event was created in database
+	// Verify no event was written to the game's log
 	gameUUID, err := stringToUUID(gameResp.Game.ID)
 	if err != nil {
 		t.Fatalf("failed to convert game ID: %v", err)
@@ -181,12 +182,8 @@ func TestWebSocketEventSending(t *testing.T) {
 		t.Fatalf("failed to get events: %v", err)
 	}
 
-	if len(events) == 0 {
-		t.Error("expected at least one event in database")
-	} else {
-		if events[0].Type != "test_event" {
-			t.Errorf("expected event type 'test_event', got %s", events[0].Type)
-		}
+	if len(events) != 0 {
+		t.Errorf("expected no events in database, got %d", len(events))
 	}
 }
 
@@ -194,7 +191,6 @@ func TestWebSocketBroadcastToMultipleClients(t *testing.T) {
 	pool := store.SetupTestDB(t)
 	defer pool.Close()
 
-	queries := db.New(pool)
 	gameStore := store.NewGameStore(pool)
 	eventHandler := NewEventHandler(nil, pool, gameStore, nil, nil)
 	hub := NewHub(eventHandler)
@@ -231,9 +227,6 @@ func TestWebSocketBroadcastToMultipleClients(t *testing.T) {
 	}
 
 	// Create test server
-	receivedEvents := make(map[string][]*store.GameEvent)
-	var mu sync.Mutex
-
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		roomID := roomResp.Room.ID
 		gameID := gameResp.Game.ID
@@ -258,20 +251,6 @@ func TestWebSocketBroadcastToMultipleClients(t *testing.T) {
 		}
 
 		hub.register <- client
-
-		// Start goroutine to receive events
-		go func() {
-			for {
-				var event store.GameEvent
-				if err := conn.ReadJSON(&event); err != nil {
-					return
-				}
-				mu.Lock()
-				receivedEvents[roomPlayerID] = append(receivedEvents[roomPlayerID], &event)
-				mu.Unlock()
-			}
-		}()
-
 		go client.writePump()
 		go client.readPump()
 	}))
@@ -302,59 +281,83 @@ func TestWebSocketBroadcastToMultipleClients(t *testing.T) {
 		t.Errorf("expected 2 clients in room, got %d", count)
 	}
 
-	// Send event from first client
-	eventReq := store.CreateGameEventRequest{
-		GameID: gameResp.Game.ID,
-		Type:   "broadcast_test",
-		Payload: map[string]interface{}{
-			"message": "broadcast message",
-		},
-	}
-
-	eventJSON, err := json.Marshal(eventReq)
-	if err != nil {
-		t.Fatalf("failed to marshal event: %v", err)
-	}
-
-	if err := conn1.WriteMessage(websocket.TextMessage, eventJSON); err != nil {
-		t.Fatalf("failed to write message: %v", err)
-	}
-
-	// Give time for event processing and broadcasting
-	time.Sleep(200 * time.Millisecond)
-
-	// Verify that player2 received the event, but player1 (sender) did not
-	mu.Lock()
-	player1Events := receivedEvents[roomResp.RoomPlayer.ID]
-	player2Events := receivedEvents[joinResp.RoomPlayer.ID]
-	mu.Unlock()
+	// Broadcast an event to the room
+	hub.Broadcast(roomResp.Room.ID, createTestGameEvent(gameResp.Game.ID, "broadcast_test", map[string]interface{}{
+		"message": "broadcast message",
+	}))
 
-	// Sender should not receive their own event
-	if len(player1Events) > 0 {
-		t.Error("player 1 (sender) should not receive their own broadcast event")
+	// Verify that both players received the event
+	for i, conn := range []*websocket.Conn{conn1, conn2} {
+		if event := readGameEvent(t, conn); event.Type != "broadcast_test" {
+			t.Errorf("client %d: expected event type 'broadcast_test', got %s", i+1, event.Type)
+		}
 	}
+}
 
-	// Other players should receive the event
-	if len(player2Events) == 0 {
-		t.Error("player 2 did not receive broadcast event")
-	} else {
-		if player2Events[0].Type != "broadcast_test" {
-			t.Errorf("player 2: expected event type 'broadcast_test', got %s", player2Events[0].Type)
+// readFrames calls next with each JSON value conn receives (the server may send several in one frame) until
+// next returns true.
+func readFrames(t *testing.T, conn *websocket.Conn, next func(data json.RawMessage) bool) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, r, err := conn.NextReader()
+		if err != nil {
+			t.Fatalf("failed to read: %v", err)
+		}
+		dec := json.NewDecoder(r)
+		for {
+			var data json.RawMessage
+			if err := dec.Decode(&data); err != nil {
+				break
+			}
+			if next(data) {
+				return
+			}
 		}
 	}
+}
 
-	// Verify event was stored in database
-	gameUUID, err := stringToUUID(gameResp.Game.ID)
-	if err != nil {
-		t.Fatalf("failed to convert game ID: %v", err)
+// isNotice reports whether env is one of the session and presence events every socket receives unprompted.
+func isNotice(env *ServerEnvelope) bool {
+	if env.Type != ServerTypeEvent {
+		return false
 	}
-
-	events, err := queries.GetGameEventsByGameId(ctx, gameUUID)
-	if err != nil {
-		t.Fatalf("failed to get events: %v", err)
+	switch env.Event {
+	case ServerEventSession, ServerEventPlayerJoined, ServerEventPlayerOnline, ServerEventPlayerOffline, ServerEventPlayerLeft:
+		return true
 	}
+	return false
+}
 
-	if len(events) == 0 {
-		t.Error("expected event in database")
-	}
+// readReply returns the next envelope conn receives, skipping session and presence notices.
+func readReply(t *testing.T, conn *websocket.Conn) ServerEnvelope {
+	t.Helper()
+	var reply ServerEnvelope
+	readFrames(t, conn, func(data json.RawMessage) bool {
+		reply = ServerEnvelope{}
+		if err := json.Unmarshal(data, &reply); err != nil {
+			t.Fatalf("failed to decode reply: %v", err)
+		}
+		return !isNotice(&reply)
+	})
+	return reply
+}
+
+// readGameEvent returns the next game event conn receives, skipping envelopes.
+func readGameEvent(t *testing.T, conn *websocket.Conn) store.GameEvent {
+	t.Helper()
+	var event store.GameEvent
+	readFrames(t, conn, func(data json.RawMessage) bool {
+		var probe struct {
+			GameID string `json:"game_id"`
+		}
+		if json.Unmarshal(data, &probe) != nil || probe.GameID == "" {
+			return false // an envelope
+		}
+		if err := json.Unmarshal(data, &event); err != nil {
+			t.Fatalf("failed to decode event: %v", err)
+		}
+		return true
+	})
+	return event
 }
@@ -4,24 +4,26 @@ import "github.com/vntrieu/avalon/internal/store"
 
 // OutgoingMessage is what the hub sends to clients; exactly one of GameEvent or Envelope is set.
 type OutgoingMessage struct {
-	GameEvent *store.GameEvent  // for game WS
-	Envelope  *ServerEnvelope   // for room WS
+	GameEvent *store.GameEvent // for game WS
+	Envelope  *ServerEnvelope  // for room WS
 }
 
 // ClientInMessage is the envelope for messages from client to server.
 // Types: "chat" | "vote" | "action" | "system"
 type ClientInMessage struct {
-	Type           string                 `json:"type"`
-	CorrelationID  string                 `json:"correlation_id,omitempty"`
-	Payload        map[string]interface{} `json:"payload,omitempty"`
+	Type          string                 `json:"type"`
+	CorrelationID string                 `json:"correlation_id,omitempty"`
+	Payload       map[string]interface{} `json:"payload,omitempty"`
 }
 
 // ServerEnvelope is the envelope for messages from server to client.
-// Type: "event" | "state" | "error"
+// Type: "event" | "state" | "error" | "ack". Replies to a client message carry its CorrelationID.
 type ServerEnvelope struct {
-	Type    string                 `json:"type"`
-	Event   string                 `json:"event,omitempty"`
-	Payload map[string]interface{} `json:"payload,omitempty"`
+	Type          string                 `json:"type"`
+	Event         string                 `json:"event,omitempty"`
+	CorrelationID string                 `json:"correlation_id,omitempty"`
+	Payload       map[string]interface{} `json:"payload,omitempty"`
+	Seq           int64                  `json:"seq,omitempty"` // room broadcasts only, for resuming a session
 }
 
 // Chat payload from client (type: "chat").
@@ -29,21 +31,41 @@ const ClientMessageTypeChat = "chat"
 
 // Client message types for game flow.
 const (
-	ClientMessageTypeVote   = "vote"
-	ClientMessageTypeAction = "action"
+	ClientMessageTypeVote      = "vote"
+	ClientMessageTypeAction    = "action"
 	ClientMessageTypeSyncState = "sync_state"
 )
 
+// SyncScopeRole is the sync_state payload "scope" that re-sends the client's role_assigned briefing.
+const SyncScopeRole = "role"
+
 // Server event types.
 const (
-	ServerEventChat          = "chat"
-	ServerEventVoteRecorded  = "vote_recorded"
-	ServerEventState         = "state"
-	ServerEventGameEnded     = "game_ended"
-	ServerEventTeamProposed  = "team_proposed"
-	ServerEventTeamApproved  = "team_approved"
-	ServerEventTeamRejected  = "team_rejected"
-	ServerEventMissionResolved = "mission_resolved"
+	ServerEventChat                 = "chat"
+	ServerEventVoteRecorded         = "vote_recorded"
+	ServerEventState                = "state"
+	ServerEventStateDelta           = "state_delta"
+	ServerEventGameEnded            = "game_ended"
+	ServerEventTeamProposed         = "team_proposed"
+	ServerEventTeamApproved         = "team_approved"
+	ServerEventTeamRejected         = "team_rejected"
+	ServerEventTeamVoteRevealed     = "team_vote_revealed"
+	ServerEventMissionResolved      = "mission_resolved"
+	ServerEventAssassinationStarted = "assassination_started"
+	ServerEventLadyInspected        = "lady_inspected"
+	ServerEventLadyResult           = "lady_result"   // private to the inspector
+	ServerEventRoleAssigned         = "role_assigned" // private to each player
+	ServerEventGamePaused           = "game_paused"
+	ServerEventGameResumed          = "game_resumed"
+	ServerEventPlayerDisconnected   = "player_disconnected"
+	ServerEventPlayerReconnected    = "player_reconnected"
+	ServerEventSeatFlagged          = "seat_flagged"
+	ServerEventSeatReplaced         = "seat_replaced"
+	ServerEventPlayerJoined         = "player_joined"  // presence: new to the room's roster
+	ServerEventPlayerOnline         = "player_online"  // presence: back online
+	ServerEventPlayerOffline        = "player_offline" // presence: last connection closed
+	ServerEventPlayerLeft           = "player_left"    // presence: offline for PresenceTimeout
+	ServerEventSession              = "session"        // first message on each connection
 )
 
 // Server envelope types.
@@ -51,6 +73,19 @@ const (
 	ServerTypeEvent = "event"
 	ServerTypeState = "state"
 	ServerTypeError = "error"
+	ServerTypeAck   = "ack"
+)
+
+// Error codes of "error" envelopes that are not about a game move. Rejected moves carry the games.Code* codes.
+const (
+	ErrorCodeInvalidMessage  = "INVALID_MESSAGE"
+	ErrorCodeUnsupportedType = "UNSUPPORTED_MESSAGE_TYPE"
+	ErrorCodeUnavailable     = "UNAVAILABLE"
+	ErrorCodeNoGame          = "NO_GAME"
+	ErrorCodeNoRole          = "NO_ROLE"
+	ErrorCodeRateLimited     = "RATE_LIMITED"
+	ErrorCodeSpectator       = "SPECTATOR_READ_ONLY"
+	ErrorCodeReadOnly        = "READ_ONLY"
 )
 
 // MaxChatMessageLength is the maximum allowed length for a chat message.
@@ -61,8 +96,13 @@ const MaxClientMessageTypeLength = 64
 
 // ValidClientMessageTypes are the only allowed values for ClientInMessage.Type (room WS).
 var ValidClientMessageTypes = map[string]bool{
-	ClientMessageTypeChat:     true,
-	ClientMessageTypeVote:     true,
-	ClientMessageTypeAction:   true,
+	ClientMessageTypeChat:      true,
+	ClientMessageTypeVote:      true,
+	ClientMessageTypeAction:    true,
+	ClientMessageTypeSyncState: true,
+}
+
+// SpectatorMessageTypes are the client message types a spectator may send; spectators only watch.
+var SpectatorMessageTypes = map[string]bool{
 	ClientMessageTypeSyncState: true,
 }
@@ -0,0 +1,113 @@
+package websocket
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// CloseSlowClient is the close code sent to a client evicted because it fell behind: it should reconnect
+// (resuming its session) once it can keep up.
+const CloseSlowClient = websocket.CloseTryAgainLater
+
+const closeReasonSlow = "too slow: outbound buffer full"
+
+// OutboundStats counts what the hub did with messages its clients could not take in time.
+type OutboundStats struct {
+	Merged  int64 `json:"merged"`  // states replaced by a newer state before being sent
+	Dropped int64 `json:"dropped"` // messages not sent because the client was evicted or gone
+	Evicted int64 `json:"evicted"` // clients disconnected for falling behind
+}
+
+type outboundCounters struct {
+	merged, dropped, evicted atomic.Int64
+}
+
+// OutboundStats returns the hub's outbound counters since it started.
+func (h *Hub) OutboundStats() OutboundStats {
+	return OutboundStats{
+		Merged:  h.outbound.merged.Load(),
+		Dropped: h.outbound.dropped.Load(),
+		Evicted: h.outbound.evicted.Load(),
+	}
+}
+
+// queue hands out to the client's writePump without blocking. When the send buffer is full, a full state
+// waits beside it, replaced by any newer state until it is written (states carry everything the client
+// needs, so only the latest matters). Any other message that does not fit evicts the client: it is closed
+// with CloseSlowClient instead of silently missing messages, and what is sent to it afterwards is dropped.
+func (c *Client) queue(out *OutgoingMessage) {
+	c.outMu.Lock()
+	defer c.outMu.Unlock()
+	if c.closed || c.evicted {
+		c.count(func(o *outboundCounters) { o.dropped.Add(1) })
+		return
+	}
+	state := isFullState(out)
+	if state && c.pending != nil {
+		c.pending = out
+		c.count(func(o *outboundCounters) { o.merged.Add(1) })
+		return
+	}
+	select {
+	case c.send <- out:
+		return
+	default:
+	}
+	if state {
+		c.pending = out
+		return
+	}
+	c.evicted = true
+	c.count(func(o *outboundCounters) {
+		o.dropped.Add(1)
+		o.evicted.Add(1)
+	})
+	log.Printf("ws client evicted room_id=%s player_id=%s: %s", c.RoomID, c.RoomPlayerID, closeReasonSlow)
+	go c.closeWith(CloseSlowClient, closeReasonSlow)
+}
+
+// takePending returns the state waiting for room in the send buffer, if any.
+func (c *Client) takePending() *OutgoingMessage {
+	c.outMu.Lock()
+	defer c.outMu.Unlock()
+	out := c.pending
+	c.pending = nil
+	return out
+}
+
+// closeSend closes the send channel once the hub has unregistered the client; later messages are dropped.
+func (c *Client) closeSend() {
+	c.outMu.Lock()
+	defer c.outMu.Unlock()
+	if !c.closed {
+		c.closed = true
+		close(c.send)
+	}
+}
+
+// closeWith sends a close frame with code and reason and closes the connection; readPump then unregisters
+// the client.
+func (c *Client) closeWith(code int, reason string) {
+	if c.conn == nil {
+		return
+	}
+	msg := websocket.FormatCloseMessage(code, reason)
+	if err := c.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeWait)); err != nil {
+		log.Printf("ws close room_id=%s player_id=%s: %v", c.RoomID, c.RoomPlayerID, err)
+	}
+	c.conn.Close()
+}
+
+// count updates the hub's outbound counters, if the client has a hub.
+func (c *Client) count(update func(*outboundCounters)) {
+	if c.hub != nil {
+		update(&c.hub.outbound)
+	}
+}
+
+func isFullState(out *OutgoingMessage) bool {
+	return out.Envelope != nil && out.Envelope.Type == ServerTypeState && out.Envelope.Event == ServerEventState
+}
@@ -0,0 +1,187 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/vntrieu/avalon/internal/db"
+)
+
+// DefaultHubChannel is the Postgres NOTIFY channel PGNotifyBackend uses.
+const DefaultHubChannel = "avalon_hub"
+
+// MaxNotifyPayload is the largest NOTIFY payload sent inline; Postgres rejects payloads of 8000 bytes or more.
+// Larger broadcasts are stored in hub_messages and only their id is notified.
+const MaxNotifyPayload = 7900
+
+// HubMessageTTL is how long a stored broadcast is kept for the other instances to load.
+const HubMessageTTL = time.Minute
+
+// publishQueueSize bounds the broadcasts waiting to be sent; when it is full new ones are dropped.
+const publishQueueSize = 1024
+
+// PGNotifyBackend is a Backend using Postgres LISTEN/NOTIFY on the app's pgx pool. Every instance listens on
+// one channel on a dedicated connection and skips its own notifications. Broadcasts are sent in order by one
+// goroutine. Notifications sent while an instance is reconnecting its listener are lost; clients catch up
+// with sync_state.
+type PGNotifyBackend struct {
+	pool        *pgxpool.Pool
+	queries     *db.Queries
+	channel     string
+	origin      string // this instance
+	queue       chan *RemoteBroadcast
+	lastCleanup time.Time
+}
+
+// NewPGNotifyBackend creates a PGNotifyBackend on DefaultHubChannel.
+func NewPGNotifyBackend(pool *pgxpool.Pool) *PGNotifyBackend {
+	return &PGNotifyBackend{
+		pool:    pool,
+		queries: db.New(pool),
+		channel: DefaultHubChannel,
+		origin:  uuid.NewString(),
+		queue:   make(chan *RemoteBroadcast, publishQueueSize),
+	}
+}
+
+// notification is a NOTIFY payload: the broadcast itself, or the id of its hub_messages row when it is too large.
+type notification struct {
+	Origin  string           `json:"origin"`
+	Message *RemoteBroadcast `json:"message,omitempty"`
+	Ref     int64            `json:"ref,omitempty"`
+}
+
+// Publish queues msg for the other instances.
+func (b *PGNotifyBackend) Publish(msg *RemoteBroadcast) {
+	select {
+	case b.queue <- msg:
+	default:
+		log.Printf("hub backend: publish queue full, dropping broadcast room_id=%s", msg.RoomID)
+	}
+}
+
+// Run sends queued broadcasts and delivers the other instances' broadcasts until ctx is done,
+// reconnecting the listener after errors.
+func (b *PGNotifyBackend) Run(ctx context.Context, deliver func(*RemoteBroadcast)) {
+	go b.sendLoop(ctx)
+	for {
+		err := b.listen(ctx, deliver)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("hub backend: listen on %s: %v; reconnecting", b.channel, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (b *PGNotifyBackend) sendLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-b.queue:
+			if err := b.send(ctx, msg); err != nil {
+				log.Printf("hub backend: send room_id=%s: %v", msg.RoomID, err)
+			}
+		}
+	}
+}
+
+// send notifies the channel of msg, storing it in hub_messages first if it is too large to inline.
+func (b *PGNotifyBackend) send(ctx context.Context, msg *RemoteBroadcast) error {
+	payload, err := json.Marshal(notification{Origin: b.origin, Message: msg})
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+	if len(payload) > MaxNotifyPayload {
+		body, err := json.Marshal(msg)
+		if err != nil {
+			return fmt.Errorf("marshal: %w", err)
+		}
+		id, err := b.queries.CreateHubMessage(ctx, body)
+		if err != nil {
+			return fmt.Errorf("store large broadcast: %w", err)
+		}
+		if payload, err = json.Marshal(notification{Origin: b.origin, Ref: id}); err != nil {
+			return fmt.Errorf("marshal: %w", err)
+		}
+		b.cleanup(ctx)
+	}
+	return b.queries.NotifyHub(ctx, db.NotifyHubParams{Channel: b.channel, Payload: string(payload)})
+}
+
+// cleanup deletes stored broadcasts older than HubMessageTTL, at most once per HubMessageTTL.
+func (b *PGNotifyBackend) cleanup(ctx context.Context) {
+	now := time.Now()
+	if now.Sub(b.lastCleanup) < HubMessageTTL {
+		return
+	}
+	b.lastCleanup = now
+	before := pgtype.Timestamptz{Time: now.Add(-HubMessageTTL), Valid: true}
+	if err := b.queries.DeleteHubMessagesBefore(ctx, before); err != nil {
+		log.Printf("hub backend: delete old broadcasts: %v", err)
+	}
+}
+
+// listen holds a connection listening on the channel and delivers each notification until an error.
+func (b *PGNotifyBackend) listen(ctx context.Context, deliver func(*RemoteBroadcast)) error {
+	pooled, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire: %w", err)
+	}
+	// LISTEN belongs to the session, so the connection leaves the pool for good.
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{b.channel}.Sanitize()); err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		msg, err := b.decode(ctx, n.Payload)
+		if err != nil {
+			log.Printf("hub backend: %v", err)
+			continue
+		}
+		if msg != nil {
+			deliver(msg)
+		}
+	}
+}
+
+// decode returns the broadcast in a notification payload, loading it from hub_messages if it was stored there.
+// Returns nil for this instance's own notifications.
+func (b *PGNotifyBackend) decode(ctx context.Context, payload string) (*RemoteBroadcast, error) {
+	var n notification
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		return nil, fmt.Errorf("decode notification: %w", err)
+	}
+	if n.Origin == b.origin {
+		return nil, nil
+	}
+	if n.Message != nil {
+		return n.Message, nil
+	}
+	body, err := b.queries.GetHubMessage(ctx, n.Ref)
+	if err != nil {
+		return nil, fmt.Errorf("load broadcast %d: %w", n.Ref, err)
+	}
+	var msg RemoteBroadcast
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, fmt.Errorf("decode broadcast %d: %w", n.Ref, err)
+	}
+	return &msg, nil
+}
@@ -0,0 +1,46 @@
+package websocket
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vntrieu/avalon/internal/store"
+)
+
+func TestPGNotifyBackend(t *testing.T) {
+	pool := store.SetupTestDB(t)
+	defer pool.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sender := NewPGNotifyBackend(pool)
+	receiver := NewPGNotifyBackend(pool)
+	sent := make(chan *RemoteBroadcast, 1)
+	received := make(chan *RemoteBroadcast, 2)
+	go sender.Run(ctx, func(msg *RemoteBroadcast) { sent <- msg })
+	go receiver.Run(ctx, func(msg *RemoteBroadcast) { received <- msg })
+	time.Sleep(200 * time.Millisecond) // let both listeners start
+
+	small := &RemoteBroadcast{RoomID: "room-1", Envelope: &ServerEnvelope{Type: ServerTypeEvent, Event: ServerEventChat, Payload: map[string]interface{}{"message": "hi"}}}
+	large := &RemoteBroadcast{RoomID: "room-1", Envelope: &ServerEnvelope{Type: ServerTypeEvent, Event: ServerEventChat, Payload: map[string]interface{}{"message": strings.Repeat("x", 2*MaxNotifyPayload)}}}
+	sender.Publish(small)
+	sender.Publish(large)
+
+	for i, want := range []*RemoteBroadcast{small, large} {
+		select {
+		case got := <-received:
+			if got.RoomID != want.RoomID || got.Envelope.Payload["message"] != want.Envelope.Payload["message"] {
+				t.Errorf("broadcast %d: expected %.40v, got %.40v", i, want.Envelope.Payload, got.Envelope.Payload)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("broadcast %d: not received", i)
+		}
+	}
+	select {
+	case msg := <-sent:
+		t.Errorf("expected the sender to skip its own broadcasts, got %+v", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
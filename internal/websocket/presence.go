@@ -0,0 +1,311 @@
+package websocket
+
+import (
+	"sort"
+	"time"
+)
+
+// PresenceTimeout is how long a room member may stay offline before they leave the roster (player_left).
+const PresenceTimeout = 2 * time.Minute
+
+// PresenceRefresh is how often a hub republishes its rooms' connection counts through the backend. Counts from
+// an instance not heard from for three refreshes are dropped, as if its connections closed.
+const PresenceRefresh = 30 * time.Second
+
+// presence is a room member in the hub's roster. A member is online while they have at least one connection
+// on any instance, so closing one of several tabs does not take them offline. Each hub publishes its own
+// connection counts (see PresenceCounts), adds up the others' and announces the changes to its own clients.
+type presence struct {
+	displayName  string
+	spectator    bool
+	offlineSince time.Time // zero while online
+	seated       bool      // has a room WS player connection (see playerSocket) on some instance
+}
+
+// presenceExpiry asks the hub loop to drop a member who went offline at since, unless they came back.
+type presenceExpiry struct {
+	roomID   string
+	playerID string
+	since    time.Time
+}
+
+// RosterEntry is a room member in the roster sent with sync_state.
+type RosterEntry struct {
+	PlayerID     string `json:"player_id"`
+	DisplayName  string `json:"display_name"`
+	Spectator    bool   `json:"spectator,omitempty"`
+	Online       bool   `json:"online"`
+	OfflineSince string `json:"offline_since,omitempty"` // RFC 3339, while offline
+}
+
+// MemberCount is a room member's connections on one instance.
+type MemberCount struct {
+	PlayerID    string `json:"player_id"`
+	DisplayName string `json:"display_name"`
+	Spectator   bool   `json:"spectator,omitempty"`
+	Sockets     int    `json:"sockets"`
+	Seats       int    `json:"seats"` // room WS player connections (see playerSocket)
+}
+
+// PresenceCounts is one instance's connections in a room, published whenever they change and every
+// PresenceRefresh. No members means the instance has no connections left in the room. Ask is set when the
+// instance has just started following the room: the others then publish their counts for it.
+type PresenceCounts struct {
+	Instance string        `json:"instance"`
+	Members  []MemberCount `json:"members"`
+	Ask      bool          `json:"ask,omitempty"`
+}
+
+// remoteCounts is the latest PresenceCounts of another instance in a room.
+type remoteCounts struct {
+	members map[string]MemberCount // by player_id
+	seen    time.Time
+}
+
+// connectionChange is a player whose seat became connected or disconnected (see EventHandler.UpdateConnection).
+type connectionChange struct {
+	roomID   string
+	playerID string
+}
+
+// memberOf describes client's member for the roster.
+func memberOf(client *Client) MemberCount {
+	return MemberCount{PlayerID: client.RoomPlayerID, DisplayName: client.DisplayName, Spectator: client.Spectator}
+}
+
+// connections counts playerID's connections in a room on all instances: all of them, and those that keep
+// their seat connected (see playerSocket). The caller holds h.mu.
+func (h *Hub) connections(roomID string, playerID string) (sockets int, seats int) {
+	for client := range h.rooms[roomID] {
+		if client.RoomPlayerID == playerID {
+			sockets++
+			if playerSocket(client) {
+				seats++
+			}
+		}
+	}
+	for _, counts := range h.remote[roomID] {
+		m := counts.members[playerID]
+		sockets += m.Sockets
+		seats += m.Seats
+	}
+	return sockets, seats
+}
+
+// reconcile brings m's roster entry in line with their connections on all instances after they changed, and
+// announces the change (player_joined, player_online or player_offline) to this instance's clients except
+// exclude. Returns true if their seat became connected or disconnected. The caller holds h.mu.
+func (h *Hub) reconcile(roomID string, m MemberCount, exclude *Client) bool {
+	if m.PlayerID == "" {
+		return false
+	}
+	sockets, seats := h.connections(roomID, m.PlayerID)
+	p := h.roster[roomID][m.PlayerID]
+	switch {
+	case sockets > 0 && (p == nil || !p.offlineSince.IsZero()):
+		h.deliver(&BroadcastMessage{RoomID: roomID, Envelope: h.markOnline(roomID, m), ExcludeClient: exclude})
+	case sockets == 0 && p != nil && p.offlineSince.IsZero():
+		h.deliver(&BroadcastMessage{RoomID: roomID, Envelope: h.markOffline(roomID, m.PlayerID)})
+	}
+	p = h.roster[roomID][m.PlayerID]
+	if p == nil || p.seated == (seats > 0) {
+		return false
+	}
+	p.seated = seats > 0
+	return true
+}
+
+// markOnline records m as online and returns the player_joined (new to the roster) or player_online envelope.
+// The caller holds h.mu.
+func (h *Hub) markOnline(roomID string, m MemberCount) *ServerEnvelope {
+	members := h.roster[roomID]
+	if members == nil {
+		members = make(map[string]*presence)
+		h.roster[roomID] = members
+	}
+	event := ServerEventPlayerOnline
+	p, ok := members[m.PlayerID]
+	if !ok {
+		p = &presence{}
+		members[m.PlayerID] = p
+		event = ServerEventPlayerJoined
+	}
+	p.displayName, p.spectator, p.offlineSince = m.DisplayName, m.Spectator, time.Time{}
+	return presenceEnvelope(event, m.PlayerID, p)
+}
+
+// markOffline records playerID as offline after their last connection closed, schedules their removal
+// after h.presenceTimeout and returns the player_offline envelope. The caller holds h.mu.
+func (h *Hub) markOffline(roomID string, playerID string) *ServerEnvelope {
+	p := h.roster[roomID][playerID]
+	if p == nil {
+		return nil
+	}
+	p.offlineSince = time.Now()
+	expiry := presenceExpiry{roomID: roomID, playerID: playerID, since: p.offlineSince}
+	time.AfterFunc(h.presenceTimeout, func() { h.expire <- expiry })
+	return presenceEnvelope(ServerEventPlayerOffline, playerID, p)
+}
+
+// markLeft drops a member still offline since expiry.since from the roster and returns the player_left envelope,
+// or nil if they came back meanwhile. The caller holds h.mu.
+func (h *Hub) markLeft(expiry presenceExpiry) *ServerEnvelope {
+	members := h.roster[expiry.roomID]
+	p := members[expiry.playerID]
+	if p == nil || !p.offlineSince.Equal(expiry.since) {
+		return nil
+	}
+	delete(members, expiry.playerID)
+	if len(members) == 0 {
+		delete(h.roster, expiry.roomID)
+	}
+	return presenceEnvelope(ServerEventPlayerLeft, expiry.playerID, p)
+}
+
+func presenceEnvelope(event string, playerID string, p *presence) *ServerEnvelope {
+	payload := map[string]interface{}{"player_id": playerID, "display_name": p.displayName}
+	if p.spectator {
+		payload["spectator"] = true
+	}
+	return &ServerEnvelope{Type: ServerTypeEvent, Event: event, Payload: payload}
+}
+
+// Roster returns the room's members on all instances, online or offline for less than PresenceTimeout,
+// sorted by display name.
+func (h *Hub) Roster(roomID string) []RosterEntry {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	roster := make([]RosterEntry, 0, len(h.roster[roomID]))
+	for playerID, p := range h.roster[roomID] {
+		entry := RosterEntry{PlayerID: playerID, DisplayName: p.displayName, Spectator: p.spectator, Online: p.offlineSince.IsZero()}
+		if !entry.Online {
+			entry.OfflineSince = p.offlineSince.UTC().Format(time.RFC3339)
+		}
+		roster = append(roster, entry)
+	}
+	sort.Slice(roster, func(i, j int) bool {
+		if roster[i].DisplayName != roster[j].DisplayName {
+			return roster[i].DisplayName < roster[j].DisplayName
+		}
+		return roster[i].PlayerID < roster[j].PlayerID
+	})
+	return roster
+}
+
+// localCounts returns this instance's connections in a room by member, sorted by player_id. The caller holds h.mu.
+func (h *Hub) localCounts(roomID string) []MemberCount {
+	byPlayer := make(map[string]*MemberCount)
+	for client := range h.rooms[roomID] {
+		if client.RoomPlayerID == "" {
+			continue
+		}
+		m := byPlayer[client.RoomPlayerID]
+		if m == nil {
+			member := memberOf(client)
+			m = &member
+			byPlayer[client.RoomPlayerID] = m
+		}
+		m.Sockets++
+		if playerSocket(client) {
+			m.Seats++
+		}
+	}
+	members := make([]MemberCount, 0, len(byPlayer))
+	for _, m := range byPlayer {
+		members = append(members, *m)
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].PlayerID < members[j].PlayerID })
+	return members
+}
+
+// publishCounts publishes this instance's connections in a room, asking the others for theirs if ask is set.
+// The caller holds h.mu.
+func (h *Hub) publishCounts(roomID string, ask bool) {
+	if h.backend == nil {
+		return
+	}
+	counts := &PresenceCounts{Instance: h.instance, Members: h.localCounts(roomID), Ask: ask}
+	h.backend.Publish(&RemoteBroadcast{RoomID: roomID, Presence: counts})
+}
+
+// applyCounts records another instance's connections in a room and reconciles the members whose
+// connections it changed. The caller holds h.mu.
+func (h *Hub) applyCounts(roomID string, counts *PresenceCounts, now time.Time) []connectionChange {
+	if counts.Instance == h.instance {
+		return nil
+	}
+	changed := make(map[string]MemberCount)
+	if prev := h.remote[roomID][counts.Instance]; prev != nil {
+		for id, m := range prev.members {
+			changed[id] = m
+		}
+	}
+	if len(counts.Members) == 0 {
+		delete(h.remote[roomID], counts.Instance)
+		if len(h.remote[roomID]) == 0 {
+			delete(h.remote, roomID)
+		}
+	} else {
+		if h.remote[roomID] == nil {
+			h.remote[roomID] = make(map[string]*remoteCounts)
+		}
+		entry := &remoteCounts{members: make(map[string]MemberCount, len(counts.Members)), seen: now}
+		for _, m := range counts.Members {
+			if prev, ok := changed[m.PlayerID]; ok && prev == m {
+				delete(changed, m.PlayerID)
+			} else {
+				changed[m.PlayerID] = m
+			}
+			entry.members[m.PlayerID] = m
+		}
+		h.remote[roomID][counts.Instance] = entry
+	}
+	changes := h.reconcileAll(roomID, changed)
+	if counts.Ask && len(h.rooms[roomID]) > 0 {
+		h.publishCounts(roomID, false)
+	}
+	return changes
+}
+
+// refreshCounts republishes this instance's connections in each of its rooms and drops the counts of instances
+// not heard from for three PresenceRefresh periods. The caller holds h.mu.
+func (h *Hub) refreshCounts(now time.Time) []connectionChange {
+	var changes []connectionChange
+	for roomID := range h.rooms {
+		h.publishCounts(roomID, false)
+	}
+	for roomID, instances := range h.remote {
+		changed := make(map[string]MemberCount)
+		for instance, counts := range instances {
+			if now.Sub(counts.seen) < 3*h.presenceRefresh {
+				continue
+			}
+			delete(instances, instance)
+			for id, m := range counts.members {
+				changed[id] = m
+			}
+		}
+		if len(instances) == 0 {
+			delete(h.remote, roomID)
+		}
+		changes = append(changes, h.reconcileAll(roomID, changed)...)
+	}
+	return changes
+}
+
+// reconcileAll reconciles members in player_id order. The caller holds h.mu.
+func (h *Hub) reconcileAll(roomID string, members map[string]MemberCount) []connectionChange {
+	var changes []connectionChange
+	ids := make([]string, 0, len(members))
+	for id := range members {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if h.reconcile(roomID, members[id], nil) {
+			changes = append(changes, connectionChange{roomID: roomID, playerID: id})
+		}
+	}
+	h.forgetRoom(roomID)
+	return changes
+}
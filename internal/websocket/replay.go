@@ -0,0 +1,141 @@
+package websocket
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// ReplayBufferSize is how many recent broadcasts each room keeps for clients resuming a session.
+const ReplayBufferSize = 512
+
+// roomReplay numbers a room's broadcasts and keeps the latest ReplayBufferSize of them, so a client that
+// reconnects with its session ID and the last seq it saw gets what it missed instead of a full resync.
+// Sequence numbers are per instance: a session this instance does not know is resynced.
+type roomReplay struct {
+	seq      int64
+	entries  []replayEntry
+	sessions map[string]string // session ID -> room_player_id
+}
+
+type replayEntry struct {
+	seq            int64
+	message        *BroadcastMessage
+	excludeSession string // the sender's session, for broadcasts that left the sender out
+}
+
+// add numbers message and keeps it for replay. Returns its seq.
+func (r *roomReplay) add(message *BroadcastMessage) int64 {
+	r.seq++
+	entry := replayEntry{seq: r.seq, message: message}
+	if message.ExcludeClient != nil {
+		entry.excludeSession = message.ExcludeClient.SessionID
+	}
+	r.entries = append(r.entries, entry)
+	if len(r.entries) > ReplayBufferSize {
+		r.entries = r.entries[len(r.entries)-ReplayBufferSize:]
+	}
+	return r.seq
+}
+
+// since returns the entries after lastSeq, or false if the buffer no longer holds all of them.
+func (r *roomReplay) since(lastSeq int64) ([]replayEntry, bool) {
+	if lastSeq < 0 || lastSeq > r.seq {
+		return nil, false
+	}
+	if len(r.entries) == 0 {
+		return nil, lastSeq == r.seq
+	}
+	first := r.entries[0].seq
+	if lastSeq < first-1 {
+		return nil, false
+	}
+	if lastSeq < first {
+		return r.entries, true
+	}
+	return r.entries[lastSeq-first+1:], true
+}
+
+// roomReplayFor returns the room's replay state, creating it if needed. The caller holds h.mu.
+func (h *Hub) roomReplayFor(roomID string) *roomReplay {
+	r := h.replay[roomID]
+	if r == nil {
+		r = &roomReplay{sessions: make(map[string]string)}
+		h.replay[roomID] = r
+	}
+	return r
+}
+
+// openSession gives a newly registered client its session. A client resuming a session of the same player
+// whose missed broadcasts are all still buffered (and fit its send buffer) gets them now, after the session
+// event; resync is true if it asked to resume but cannot, so it needs the full state. The caller holds h.mu.
+func (h *Hub) openSession(client *Client) (resync bool) {
+	r := h.roomReplayFor(client.RoomID)
+	var missed []replayEntry
+	resumed := false
+	if client.resuming {
+		if r.sessions[client.SessionID] == client.RoomPlayerID {
+			missed, resumed = r.since(client.lastSeq)
+			resumed = resumed && len(missed) < cap(client.send)
+		}
+		resync = !resumed
+	}
+	if !resumed {
+		client.SessionID = uuid.NewString()
+		missed = nil
+	}
+	r.sessions[client.SessionID] = client.RoomPlayerID
+	client.resuming = false
+
+	sendEnvelopeToClient(client, &ServerEnvelope{Type: ServerTypeEvent, Event: ServerEventSession, Payload: map[string]interface{}{
+		"session_id": client.SessionID,
+		"seq":        r.seq,
+		"resumed":    resumed,
+		"replayed":   len(missed),
+	}})
+	for _, entry := range missed {
+		if entry.excludeSession == client.SessionID {
+			continue
+		}
+		if out := outgoingFor(entry.message, entry.seq, client); out != nil {
+			sendEnvelopeToClient(client, out.Envelope)
+		}
+	}
+	return resync
+}
+
+// dropSessions forgets playerID's sessions in a room, once they have left it. The caller holds h.mu.
+func (h *Hub) dropSessions(roomID string, playerID string) {
+	r := h.replay[roomID]
+	if r == nil {
+		return
+	}
+	for sessionID, owner := range r.sessions {
+		if owner == playerID {
+			delete(r.sessions, sessionID)
+		}
+	}
+}
+
+// forgetRoom drops a room's replay state once it has neither clients nor roster members left to resume.
+// The caller holds h.mu.
+func (h *Hub) forgetRoom(roomID string) {
+	if len(h.rooms[roomID]) == 0 && len(h.roster[roomID]) == 0 {
+		delete(h.replay, roomID)
+	}
+}
+
+// resumeParams reads the session_id and last_seq query params a reconnecting client sends to resume its session.
+func resumeParams(r *http.Request, client *Client) {
+	sessionID := r.URL.Query().Get("session_id")
+	if sessionID == "" {
+		return
+	}
+	client.SessionID = sessionID
+	client.resuming = true
+	client.lastSeq = -1
+	if v, err := strconv.ParseInt(r.URL.Query().Get("last_seq"), 10, 64); err == nil {
+		client.lastSeq = v
+	}
+}
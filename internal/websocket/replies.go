@@ -0,0 +1,203 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/vntrieu/avalon/internal/db"
+	"github.com/vntrieu/avalon/internal/games"
+)
+
+// MaxCorrelationIDLength limits the correlation_id a client may send.
+const MaxCorrelationIDLength = 128
+
+// ReplyTTL is how long the reply to a correlation_id is kept, so a client retrying within it gets the same reply
+// instead of the message being applied twice.
+const ReplyTTL = 10 * time.Minute
+
+// ReplyWaitTimeout bounds how long a retry waits for the reply to the attempt still being handled; it gets no
+// reply if the first attempt takes longer. It is also how long a claim in ws_replies lasts.
+const ReplyWaitTimeout = 10 * time.Second
+
+// replyPollInterval is how often a retry waiting on another instance's attempt checks ws_replies.
+const replyPollInterval = 100 * time.Millisecond
+
+// replyCache remembers the ack or error sent for each (room, player, correlation_id). Entries are shared by all
+// of a player's connections, so a retry after a reconnect is recognised. With queries set they are kept in
+// ws_replies and shared by all instances; without, each instance only knows its own.
+type replyCache struct {
+	mu        sync.Mutex
+	entries   map[replyKey]*replyEntry
+	ttl       time.Duration
+	wait      time.Duration // ReplyWaitTimeout
+	lastSweep time.Time
+	queries   *db.Queries
+}
+
+type replyKey struct {
+	roomID, playerID, correlationID string
+}
+
+type replyEntry struct {
+	done    chan struct{} // closed once reply is set
+	reply   *ServerEnvelope
+	expires time.Time
+}
+
+func newReplyCache(ttl time.Duration, queries *db.Queries) *replyCache {
+	return &replyCache{entries: make(map[replyKey]*replyEntry), ttl: ttl, wait: ReplyWaitTimeout, queries: queries}
+}
+
+func replyKeyFor(client *Client, correlationID string) replyKey {
+	return replyKey{roomID: client.RoomID, playerID: client.RoomPlayerID, correlationID: correlationID}
+}
+
+// begin claims key. It returns (nil, true) if the caller should handle the message and then call finish,
+// or the earlier reply if the message was already handled. A retry that arrives while the first attempt is
+// still running waits for its reply; it returns (nil, false) if ctx ends or ReplyWaitTimeout passes first.
+func (c *replyCache) begin(ctx context.Context, key replyKey) (*ServerEnvelope, bool) {
+	ctx, cancel := context.WithTimeout(ctx, c.wait)
+	defer cancel()
+	if c.queries != nil {
+		return c.beginShared(ctx, key)
+	}
+	now := time.Now()
+	c.mu.Lock()
+	c.sweep(now)
+	entry, ok := c.entries[key]
+	if !ok || (entry.reply != nil && now.After(entry.expires)) {
+		c.entries[key] = &replyEntry{done: make(chan struct{})}
+		c.mu.Unlock()
+		return nil, true
+	}
+	c.mu.Unlock()
+	select {
+	case <-entry.done:
+		return entry.reply, false
+	case <-ctx.Done():
+		return nil, false
+	}
+}
+
+// beginShared is begin against ws_replies: it claims the row, or polls it for the reply of the instance that
+// holds the claim. If the database fails the message is handled rather than lost.
+func (c *replyCache) beginShared(ctx context.Context, key replyKey) (*ServerEnvelope, bool) {
+	for {
+		_, err := c.queries.ClaimWsReply(ctx, db.ClaimWsReplyParams{
+			RoomID:        key.roomID,
+			PlayerID:      key.playerID,
+			CorrelationID: key.correlationID,
+			ExpiresAt:     pgtype.Timestamptz{Time: time.Now().Add(c.wait), Valid: true},
+		})
+		if err == nil {
+			return nil, true
+		}
+		if err != pgx.ErrNoRows {
+			if ctx.Err() != nil {
+				return nil, false
+			}
+			log.Printf("ws reply claim room_id=%s player_id=%s: %v", key.roomID, key.playerID, err)
+			return nil, true
+		}
+		body, err := c.queries.GetWsReply(ctx, db.GetWsReplyParams{RoomID: key.roomID, PlayerID: key.playerID, CorrelationID: key.correlationID})
+		if err == nil && body != nil {
+			var reply ServerEnvelope
+			if err := json.Unmarshal(body, &reply); err != nil {
+				log.Printf("ws reply decode room_id=%s player_id=%s: %v", key.roomID, key.playerID, err)
+				return nil, false
+			}
+			return &reply, false
+		}
+		// Still being handled, or deleted after a transient error (the next claim then succeeds).
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-time.After(replyPollInterval):
+		}
+	}
+}
+
+// finish records the reply to key and wakes retries waiting on it. Replies to failures that may pass on
+// a retry (stale state, server errors, rate limits) are not kept, so the retry is handled again.
+func (c *replyCache) finish(ctx context.Context, key replyKey, reply *ServerEnvelope) {
+	if c.queries != nil {
+		c.finishShared(ctx, key, reply)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	entry.reply = reply
+	entry.expires = time.Now().Add(c.ttl)
+	close(entry.done)
+	if isTransientError(reply) {
+		delete(c.entries, key)
+	}
+}
+
+// finishShared is finish against ws_replies.
+func (c *replyCache) finishShared(ctx context.Context, key replyKey, reply *ServerEnvelope) {
+	var err error
+	if isTransientError(reply) {
+		err = c.queries.DeleteWsReply(ctx, db.DeleteWsReplyParams{RoomID: key.roomID, PlayerID: key.playerID, CorrelationID: key.correlationID})
+	} else {
+		var body []byte
+		if body, err = json.Marshal(reply); err == nil {
+			err = c.queries.FinishWsReply(ctx, db.FinishWsReplyParams{
+				RoomID:        key.roomID,
+				PlayerID:      key.playerID,
+				CorrelationID: key.correlationID,
+				ReplyJson:     body,
+				ExpiresAt:     pgtype.Timestamptz{Time: time.Now().Add(c.ttl), Valid: true},
+			})
+		}
+	}
+	if err != nil {
+		log.Printf("ws reply save room_id=%s player_id=%s: %v", key.roomID, key.playerID, err)
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	sweep := now.Sub(c.lastSweep) >= c.ttl
+	if sweep {
+		c.lastSweep = now
+	}
+	c.mu.Unlock()
+	if sweep {
+		if err := c.queries.DeleteExpiredWsReplies(ctx, pgtype.Timestamptz{Time: now, Valid: true}); err != nil {
+			log.Printf("ws reply cleanup: %v", err)
+		}
+	}
+}
+
+// sweep drops expired replies at most once per ttl. The caller holds c.mu.
+func (c *replyCache) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < c.ttl {
+		return
+	}
+	c.lastSweep = now
+	for key, entry := range c.entries {
+		if entry.reply != nil && now.After(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func isTransientError(reply *ServerEnvelope) bool {
+	if reply == nil || reply.Type != ServerTypeError {
+		return false
+	}
+	switch reply.Payload["code"] {
+	case games.CodeStaleState, games.CodeInternal, ErrorCodeRateLimited:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package websocket
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// DefaultTimerInterval is how often RunTimers looks for phase deadlines that have passed.
+const DefaultTimerInterval = time.Second
+
+// RunTimers applies the engine's default move (see games.Engine.ApplyTimeout) to every in-progress game whose
+// phase deadline has passed, checking every interval until ctx is done, and broadcasts the result to the room.
+// Deadlines are read from the game snapshots, so timers that ran out while the server was down fire on the first
+// check after a restart. Several servers may run timers: each timeout commits at most once per snapshot version.
+func (h *EventHandler) RunTimers(ctx context.Context, interval time.Duration) {
+	if h.gameStore == nil || h.engine == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.fireExpiredTimers(ctx)
+		}
+	}
+}
+
+// fireExpiredTimers applies and broadcasts the timeout move for each game past its deadline.
+func (h *EventHandler) fireExpiredTimers(ctx context.Context) {
+	expired, err := h.gameStore.ListGamesPastDeadline(ctx, time.Now())
+	if err != nil {
+		log.Printf("list games past deadline: %v", err)
+		return
+	}
+	for _, game := range expired {
+		result := h.engine.ApplyTimeout(ctx, game.ID)
+		if result.Error != nil {
+			log.Printf("apply timeout for game %s: %v", game.ID, result.Error)
+			continue
+		}
+		if result.State == nil {
+			continue
+		}
+		h.broadcastResult(game.RoomID, game.ID, result)
+	}
+}
@@ -42,49 +42,62 @@ func NewWSHandler(hub *Hub, pool *pgxpool.Pool, tokenSecret []byte) *WSHandler {
 	}
 }
 
-// HandleWebSocket handles WebSocket upgrade requests.
+// HandleWebSocket handles GET /api/rooms/{code}/games/{game_id}/ws: a read-only stream of the room's broadcasts for
+// clients following one game. It takes the same token as the room WS, and the game must belong to the room.
+// Moves are only accepted on the room WS.
 func (h *WSHandler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	code := chi.URLParam(r, "code")
 	gameID := chi.URLParam(r, "game_id")
-	roomPlayerID := r.URL.Query().Get("room_player_id")
-
 	if code == "" || gameID == "" {
 		http.Error(w, "code and game_id are required", http.StatusBadRequest)
 		return
 	}
-
-	// Resolve room code to room_id
-	queries := db.New(h.pool)
-	roomRow, err := queries.GetRoomByCode(r.Context(), code)
-	if err != nil {
-		log.Printf("websocket: room not found for code %q: %v", code, err)
-		http.Error(w, "room not found", http.StatusNotFound)
+	claims, roomRow, ok := h.authorize(w, r, code)
+	if !ok {
 		return
 	}
 	roomID := pgtypeUUIDToString(roomRow.ID)
-
-	// Upgrade connection to WebSocket
-	conn, err := upgrader.Upgrade(w, r, nil)
+	gameUUID, err := stringToUUID(gameID)
 	if err != nil {
-		log.Printf("websocket upgrade error: %v", err)
+		http.Error(w, "game not found", http.StatusNotFound)
+		return
+	}
+	game, err := db.New(h.pool).GetGameById(r.Context(), gameUUID)
+	if err != nil || pgtypeUUIDToString(game.RoomID) != roomID {
+		log.Printf("websocket game: code=%s game_id=%s not in room: %v", code, gameID, err)
+		http.Error(w, "game not found", http.StatusNotFound)
 		return
 	}
-
-	// Use Background so event handling is not tied to the HTTP request lifecycle.
-	// The request context is canceled when the handler returns after the upgrade.
 	client := &Client{
 		hub:          h.hub,
-		conn:         conn,
 		send:         make(chan *OutgoingMessage, 256),
 		RoomID:       roomID,
 		GameID:       gameID,
-		RoomPlayerID: roomPlayerID,
+		RoomPlayerID: claims.RoomPlayerID,
+		DisplayName:  claims.DisplayName,
+		RateLimitKey: rateLimitKeyFromRequest(r),
 		ctx:          context.Background(),
 	}
-
+	if claims.Spectator {
+		client.Spectator = true
+		client.SpectatorReveal = store.SpectatorReveal(roomRow.SettingsJson)
+	} else {
+		roomPlayer, err := store.NewRoomStore(h.pool).GetRoomPlayerInRoom(r.Context(), code, claims.RoomPlayerID)
+		if err != nil {
+			log.Printf("websocket game: code=%s player_id=%s player not in room: %v", code, claims.RoomPlayerID, err)
+			h.rejectRoomWS(w, r, "player not in room")
+			return
+		}
+		client.DisplayName = roomPlayer.DisplayName
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("websocket upgrade error: %v", err)
+		return
+	}
+	client.conn = conn
+	resumeParams(r, client)
 	client.hub.register <- client
-
-	// Start goroutines for reading and writing
 	go client.writePump()
 	go client.readPump()
 }
@@ -96,33 +109,13 @@ func (h *WSHandler) HandleRoomWebSocket(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "code is required", http.StatusBadRequest)
 		return
 	}
-	token := r.URL.Query().Get("token")
-	if token == "" {
-		const prefix = "Bearer "
-		if v := r.Header.Get("Authorization"); strings.HasPrefix(v, prefix) {
-			token = strings.TrimSpace(v[len(prefix):])
-		}
-	}
-	if token == "" || len(h.tokenSecret) == 0 {
-		h.rejectRoomWS(w, r, "missing or invalid token")
-		return
-	}
-	claims, err := auth.VerifyToken(token, h.tokenSecret)
-	if err != nil {
-		log.Printf("websocket room auth: code=%s token verification failed: %v", code, err)
-		h.rejectRoomWS(w, r, "unauthorized")
-		return
-	}
-	queries := db.New(h.pool)
-	roomRow, err := queries.GetRoomByCode(r.Context(), code)
-	if err != nil {
-		log.Printf("websocket room: room not found for code %q: %v", code, err)
-		http.Error(w, "room not found", http.StatusNotFound)
+	claims, roomRow, ok := h.authorize(w, r, code)
+	if !ok {
 		return
 	}
 	roomID := pgtypeUUIDToString(roomRow.ID)
-	if roomID != claims.RoomID {
-		h.rejectRoomWS(w, r, "room does not match token")
+	if claims.Spectator {
+		h.serveSpectator(w, r, roomID, claims, store.SpectatorReveal(roomRow.SettingsJson))
 		return
 	}
 	roomStore := store.NewRoomStore(h.pool)
@@ -148,6 +141,66 @@ func (h *WSHandler) HandleRoomWebSocket(w http.ResponseWriter, r *http.Request)
 		RateLimitKey: rateLimitKeyFromRequest(r),
 		ctx:          context.Background(),
 	}
+	resumeParams(r, client)
+	client.hub.register <- client
+	go client.writePump()
+	go client.readPump()
+}
+
+// authorize verifies the room token sent via query param or Authorization header and that it was issued for
+// the room identified by code. On failure it writes the response and returns ok false.
+func (h *WSHandler) authorize(w http.ResponseWriter, r *http.Request, code string) (claims *auth.Claims, roomRow db.GetRoomByCodeRow, ok bool) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		const prefix = "Bearer "
+		if v := r.Header.Get("Authorization"); strings.HasPrefix(v, prefix) {
+			token = strings.TrimSpace(v[len(prefix):])
+		}
+	}
+	if token == "" || len(h.tokenSecret) == 0 {
+		h.rejectRoomWS(w, r, "missing or invalid token")
+		return nil, roomRow, false
+	}
+	claims, err := auth.VerifyToken(token, h.tokenSecret)
+	if err != nil {
+		log.Printf("websocket room auth: code=%s token verification failed: %v", code, err)
+		h.rejectRoomWS(w, r, "unauthorized")
+		return nil, roomRow, false
+	}
+	roomRow, err = db.New(h.pool).GetRoomByCode(r.Context(), code)
+	if err != nil {
+		log.Printf("websocket room: room not found for code %q: %v", code, err)
+		http.Error(w, "room not found", http.StatusNotFound)
+		return nil, roomRow, false
+	}
+	if pgtypeUUIDToString(roomRow.ID) != claims.RoomID {
+		h.rejectRoomWS(w, r, "room does not match token")
+		return nil, roomRow, false
+	}
+	return claims, roomRow, true
+}
+
+// serveSpectator upgrades a spectator's room WS connection. Spectators receive the room's public events and
+// the spectator view of the state (see games.SpectatorView); they may only send sync_state.
+func (h *WSHandler) serveSpectator(w http.ResponseWriter, r *http.Request, roomID string, claims *auth.Claims, reveal bool) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("websocket room upgrade error: %v", err)
+		return
+	}
+	client := &Client{
+		hub:             h.hub,
+		conn:            conn,
+		send:            make(chan *OutgoingMessage, 256),
+		RoomID:          roomID,
+		RoomPlayerID:    claims.RoomPlayerID,
+		DisplayName:     claims.DisplayName,
+		Spectator:       true,
+		SpectatorReveal: reveal,
+		RateLimitKey:    rateLimitKeyFromRequest(r),
+		ctx:             context.Background(),
+	}
+	resumeParams(r, client)
 	client.hub.register <- client
 	go client.writePump()
 	go client.readPump()
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Broadcasts too large for a NOTIFY payload; listeners load them by id. Rows are short-lived.
+
+CREATE TABLE hub_messages (
+    id BIGSERIAL PRIMARY KEY,
+    payload_json JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_hub_messages_created_at ON hub_messages (created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS hub_messages;
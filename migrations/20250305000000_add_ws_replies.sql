@@ -0,0 +1,17 @@
+-- +goose Up
+-- Replies to room WebSocket messages by correlation_id, shared by all instances so a retry is recognised
+-- wherever it lands. A row without reply_json is a claim: an instance is handling the message until expires_at.
+
+CREATE TABLE ws_replies (
+    room_id TEXT NOT NULL,
+    player_id TEXT NOT NULL,
+    correlation_id TEXT NOT NULL,
+    reply_json JSONB,
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (room_id, player_id, correlation_id)
+);
+
+CREATE INDEX idx_ws_replies_expires_at ON ws_replies (expires_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS ws_replies;
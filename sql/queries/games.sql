@@ -42,7 +42,48 @@ WHERE game_id = $1
 ORDER BY version DESC
 LIMIT 1;
 
+-- name: GetGameStateSnapshotByVersion :one
+SELECT id, game_id, version, state_json, created_at
+FROM game_state_snapshots
+WHERE game_id = $1 AND version = $2;
+
+-- name: ListGamesPastDeadline :many
+SELECT g.id, g.room_id, g.status, g.config_json, g.created_at, g.ended_at
+FROM games g
+JOIN LATERAL (
+    SELECT state_json
+    FROM game_state_snapshots
+    WHERE game_id = g.id
+    ORDER BY version DESC
+    LIMIT 1
+) s ON true
+WHERE g.status = 'in_progress'
+  AND s.state_json ? 'deadline'
+  AND (s.state_json->>'deadline')::timestamptz <= sqlc.arg(now)::timestamptz;
+
+-- name: LockGameForUpdate :one
+SELECT id
+FROM games
+WHERE id = $1
+FOR UPDATE;
+
+-- name: SetGamePlayerLeftAt :exec
+UPDATE game_players
+SET left_at = $3
+WHERE game_id = $1 AND room_player_id = $2;
+
 -- name: UpdateGameStatus :exec
 UPDATE games
 SET status = $2, ended_at = $3
 WHERE id = $1;
+
+-- name: UpdateGamePlayerRole :exec
+UPDATE game_players
+SET role = $3
+WHERE game_id = $1 AND room_player_id = $2;
+
+-- name: UpsertGamePlayer :exec
+INSERT INTO game_players (game_id, room_player_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (game_id, room_player_id) DO UPDATE
+SET role = EXCLUDED.role, left_at = NULL;
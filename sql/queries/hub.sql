@@ -0,0 +1,16 @@
+-- name: CreateHubMessage :one
+INSERT INTO hub_messages (payload_json)
+VALUES ($1)
+RETURNING id;
+
+-- name: GetHubMessage :one
+SELECT payload_json
+FROM hub_messages
+WHERE id = $1;
+
+-- name: DeleteHubMessagesBefore :exec
+DELETE FROM hub_messages
+WHERE created_at < $1;
+
+-- name: NotifyHub :exec
+SELECT pg_notify(sqlc.arg(channel)::text, sqlc.arg(payload)::text);
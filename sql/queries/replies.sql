@@ -0,0 +1,25 @@
+-- name: ClaimWsReply :one
+INSERT INTO ws_replies (room_id, player_id, correlation_id, expires_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (room_id, player_id, correlation_id) DO UPDATE
+SET reply_json = NULL, expires_at = EXCLUDED.expires_at
+WHERE ws_replies.expires_at < NOW()
+RETURNING correlation_id;
+
+-- name: GetWsReply :one
+SELECT reply_json
+FROM ws_replies
+WHERE room_id = $1 AND player_id = $2 AND correlation_id = $3;
+
+-- name: FinishWsReply :exec
+UPDATE ws_replies
+SET reply_json = $4, expires_at = $5
+WHERE room_id = $1 AND player_id = $2 AND correlation_id = $3;
+
+-- name: DeleteWsReply :exec
+DELETE FROM ws_replies
+WHERE room_id = $1 AND player_id = $2 AND correlation_id = $3;
+
+-- name: DeleteExpiredWsReplies :exec
+DELETE FROM ws_replies
+WHERE expires_at < $1;